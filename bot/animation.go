@@ -0,0 +1,56 @@
+package bot
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/watzon/pigmentpoet/color"
+)
+
+// maxAnimationFrames caps how many frames of an animation are read, evenly
+// spread over its length
+const maxAnimationFrames = 120
+
+// getAnimation fetches and decodes an animated GIF
+func getAnimation(ctx context.Context, url string) (*color.Animation, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch animation: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch animation: %s", resp.Status)
+	}
+
+	anim, err := color.DecodeGIF(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return thinFrames(anim, maxAnimationFrames), nil
+}
+
+// thinFrames keeps at most limit frames, folding the delays of dropped frames
+// into the kept frame before them so timing is preserved
+func thinFrames(anim *color.Animation, limit int) *color.Animation {
+	if len(anim.Frames) <= limit {
+		return anim
+	}
+
+	thinned := &color.Animation{}
+	for i := range anim.Frames {
+		kept := i * limit / len(anim.Frames)
+		if kept == len(thinned.Frames) {
+			thinned.Frames = append(thinned.Frames, anim.Frames[i])
+			thinned.Delays = append(thinned.Delays, 0)
+		}
+		thinned.Delays[len(thinned.Delays)-1] += anim.Delays[i]
+	}
+	return thinned
+}
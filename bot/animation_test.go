@@ -0,0 +1,46 @@
+package bot
+
+import (
+	"image"
+	"testing"
+	"time"
+
+	"github.com/watzon/pigmentpoet/color"
+)
+
+func TestThinFrames(t *testing.T) {
+	anim := &color.Animation{}
+	for i := 0; i < 10; i++ {
+		// Frame widths identify which frames were kept
+		anim.Frames = append(anim.Frames, image.NewNRGBA(image.Rect(0, 0, i+1, 1)))
+		anim.Delays = append(anim.Delays, time.Duration(i+1)*10*time.Millisecond)
+	}
+
+	thinned := thinFrames(anim, 4)
+
+	// Frame i goes to slot i*4/10, so frames 0, 3, 5 and 8 are kept and
+	// each takes the delays of the frames dropped after it
+	wantFrames := []int{0, 3, 5, 8}
+	wantDelays := []time.Duration{60, 90, 210, 190}
+	if len(thinned.Frames) != len(wantFrames) || len(thinned.Delays) != len(wantDelays) {
+		t.Fatalf("got %d frames and %d delays, want %d", len(thinned.Frames), len(thinned.Delays), len(wantFrames))
+	}
+	var total time.Duration
+	for i, frame := range thinned.Frames {
+		if got := frame.Bounds().Dx() - 1; got != wantFrames[i] {
+			t.Errorf("slot %d: kept frame %d, want %d", i, got, wantFrames[i])
+		}
+		if want := wantDelays[i] * time.Millisecond; thinned.Delays[i] != want {
+			t.Errorf("slot %d: delay %v, want %v", i, thinned.Delays[i], want)
+		}
+		total += thinned.Delays[i]
+	}
+	if total != 550*time.Millisecond {
+		t.Errorf("delays sum to %v, want the original 550ms", total)
+	}
+
+	// Short animations are left alone
+	if got := thinFrames(anim, 10); got != anim {
+		t.Error("animation within the limit was copied")
+	}
+}
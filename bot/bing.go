@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"image"
-	_ "image/jpeg"
-	_ "image/png"
 	"net/http"
 	"strings"
+
+	"github.com/watzon/pigmentpoet/color"
 )
 
 const bingImageURL = "https://www.bing.com/HPImageArchive.aspx?format=js&idx=0&n=1&mkt=en-US"
@@ -58,7 +58,7 @@ func getBingImageOfDay(ctx context.Context) (image.Image, string, string, error)
 	defer imgResp.Body.Close()
 
 	// Decode the image
-	img, _, err := image.Decode(imgResp.Body)
+	img, _, err := color.DecodeImage(imgResp.Body)
 	if err != nil {
 		return nil, "", "", fmt.Errorf("failed to decode image: %w", err)
 	}
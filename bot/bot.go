@@ -6,8 +6,10 @@ import (
 	"fmt"
 	"image"
 	"image/jpeg"
+	"log"
 	"math/rand"
 	"os"
+	"time"
 
 	"github.com/watzon/lining/client"
 	"github.com/watzon/lining/models"
@@ -22,11 +24,37 @@ type Bot struct {
 	paletteGen *PaletteGenerator
 }
 
+// dominantShare is the share of an image a color needs to be called out as dominant
+const dominantShare = 0.4
+
+// outOfGamutShare is the share of a swatch's pixels that must have been
+// clipped to sRGB before the post warns that its hex code is approximate
+const outOfGamutShare = 0.1
+
+// bingExtractOptions favour the subject of a photo over skies, letterboxing
+// and clipped highlights, which otherwise dominate the Bing images
+var bingExtractOptions = color.ExtractOptions{
+	NumColors:          5,
+	SuppressBackground: 0.8,
+	SuppressClipped:    0.8,
+	Saliency:           0.3,
+	CenterPrior:        0.3,
+}
+
 // PaletteGenerator handles the generation of color palettes
 type PaletteGenerator struct {
 	types []color.PaletteType
+	// minScore is the quality score a random palette needs to be posted
+	minScore float64
 }
 
+// DefaultMinPaletteScore is the quality score a random palette needs by
+// default; about a third of random palettes reach it
+const DefaultMinPaletteScore = 0.6
+
+// maxPaletteAttempts is how many random palettes are scored before giving up
+const maxPaletteAttempts = 100
+
 // NewBot creates a new instance of the Bot
 func NewBot(ctx context.Context, identifier, password, outputDir string) (*Bot, error) {
 	bsky, err := client.NewClient(client.DefaultConfig().
@@ -59,7 +87,15 @@ func NewBot(ctx context.Context, identifier, password, outputDir string) (*Bot,
 			color.SplitComplementary,
 			color.Tetradic,
 			color.Monochromatic,
+			color.Square,
+			color.DoubleComplementary,
+			color.Compound,
+			color.Shades,
+			color.Tints,
+			color.Tones,
+			color.NeutralAccent,
 		},
+		minScore: DefaultMinPaletteScore,
 	}
 
 	return &Bot{
@@ -89,6 +125,41 @@ func (b *Bot) generateRandomColor() string {
 		rand.Intn(256))
 }
 
+// SetMinPaletteScore sets the quality score, from 0 to 1, that a random
+// palette needs to be posted
+func (b *Bot) SetMinPaletteScore(score float64) {
+	b.paletteGen.minScore = score
+}
+
+// generatePalette draws random palettes until one scores at least the
+// minimum, so muddy or near-duplicate swatches are not posted
+func (b *Bot) generatePalette() (color.Palette, error) {
+	var best color.PaletteScore
+	for attempt := 1; attempt <= maxPaletteAttempts; attempt++ {
+		// Pick a random base color and palette type
+		baseColor := b.generateRandomColor()
+		paletteType := b.paletteGen.types[rand.Intn(len(b.paletteGen.types))]
+
+		palette, err := b.matcher.NewGeneratedPalette(baseColor, paletteType, 5,
+			color.WithColorSpace(color.SpaceOKLCH))
+		if err != nil {
+			return color.Palette{}, err
+		}
+
+		score := color.ScorePalette(palette)
+		if score.Total >= b.paletteGen.minScore {
+			log.Printf("Picked %s palette from %s on attempt %d, scoring %s", paletteType, baseColor, attempt, score)
+			return palette, nil
+		}
+		log.Printf("Rejected %s palette from %s on attempt %d, scoring %s", paletteType, baseColor, attempt, score)
+		if score.Total > best.Total {
+			best = score
+		}
+	}
+	return color.Palette{}, fmt.Errorf("no palette scored at least %.2f in %d attempts; the best scored %s",
+		b.paletteGen.minScore, maxPaletteAttempts, best)
+}
+
 // GenerateAndPost generates a color palette and posts it to Bluesky
 func (b *Bot) GenerateAndPost(ctx context.Context) error {
 	// Ensure we have a valid session before proceeding
@@ -96,38 +167,14 @@ func (b *Bot) GenerateAndPost(ctx context.Context) error {
 		return fmt.Errorf("failed to refresh session: %w", err)
 	}
 
-	// Generate a random base color
-	baseColor := b.generateRandomColor()
-
-	// Select a random palette type
-	paletteType := b.paletteGen.types[rand.Intn(len(b.paletteGen.types))]
-
 	// Generate the palette
-	colors := b.matcher.GeneratePalette(baseColor, paletteType, 5)
-
-	// Convert colors to hex codes and get their names
-	var hexCodes []string
-	var names []string
-	for _, c := range colors {
-		hex := fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
-		hexCodes = append(hexCodes, hex)
-		if colorName, err := b.matcher.FindClosestColor(hex); err == nil {
-			names = append(names, colorName.Name)
-		} else {
-			names = append(names, "Unknown")
-		}
+	palette, err := b.generatePalette()
+	if err != nil {
+		return fmt.Errorf("failed to generate palette: %w", err)
 	}
 
 	// Generate image
-	cfg := color.PaletteImage{
-		Colors:       colors,
-		Names:        names,
-		HexCodes:     hexCodes,
-		ShowHexCodes: true,
-		ShowNames:    true,
-	}
-
-	img, err := color.GeneratePaletteImage(cfg)
+	img, err := color.GeneratePaletteImage(color.NewPaletteImage(palette))
 	if err != nil {
 		return fmt.Errorf("failed to generate palette image: %w", err)
 	}
@@ -138,9 +185,9 @@ func (b *Bot) GenerateAndPost(ctx context.Context) error {
 	}
 
 	// Create post text
-	text := fmt.Sprintf("🎨 %s\n\n", b.getPaletteTypeName(paletteType))
-	for i, name := range names {
-		text += fmt.Sprintf("%s (%s)\n", name, hexCodes[i])
+	text := fmt.Sprintf("🎨 %s\n\n", palette.Type)
+	for _, s := range palette.Swatches {
+		text += fmt.Sprintf("%s (%s)\n", s.Name, s.Color.Hex())
 	}
 
 	fmt.Printf("Posting to Bluesky: %s\n", text)
@@ -173,25 +220,20 @@ func (b *Bot) GenerateAndPostFromBing(ctx context.Context) error {
 	}
 
 	// Fetch Bing's image of the day
-	img, title, _, err := getBingImageOfDay(ctx)
+	img, title, copyright, err := getBingImageOfDay(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to get Bing image: %w", err)
 	}
 
-	// Extract palette from the image
-	colors := color.ExtractPalette(img, 5)
+	// Extract palette from the image along with each color's share of it
+	swatches := color.ExtractPaletteDetailed(img, bingExtractOptions)
+	palette := b.matcher.NewSwatchPalette(swatches)
+	palette.Source = "Bing"
+	palette.Attribution = copyright
 
-	// Get color names and hex codes
-	var names []string
-	var hexCodes []string
-	for _, c := range colors {
-		hex := fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
-		hexCodes = append(hexCodes, hex)
-		if colorName, err := b.matcher.FindClosestColor(hex); err == nil {
-			names = append(names, colorName.Name)
-		} else {
-			names = append(names, "Unknown")
-		}
+	var markers []image.Point
+	for _, s := range swatches {
+		markers = append(markers, s.Location)
 	}
 
 	// Save Bing image to temporary file
@@ -208,14 +250,9 @@ func (b *Bot) GenerateAndPostFromBing(ctx context.Context) error {
 	tmpFile.Close()
 
 	// Generate palette image with Bing image as input
-	cfg := color.PaletteImage{
-		Colors:       colors,
-		Names:        names,
-		HexCodes:     hexCodes,
-		ShowHexCodes: true,
-		ShowNames:    true,
-		InputPath:    tmpFile.Name(),
-	}
+	cfg := color.NewPaletteImage(palette)
+	cfg.InputPath = tmpFile.Name()
+	cfg.Markers = markers
 
 	paletteImg, err := color.GeneratePaletteImage(cfg)
 	if err != nil {
@@ -230,8 +267,18 @@ func (b *Bot) GenerateAndPostFromBing(ctx context.Context) error {
 
 	// Create post text
 	text := fmt.Sprintf("🎨 %s\n\n", title)
-	for i, name := range names {
-		text += fmt.Sprintf("%s (%s)\n", name, hexCodes[i])
+	if len(swatches) > 0 && swatches[0].Fraction >= dominantShare {
+		text += fmt.Sprintf("Mostly %s\n\n", palette.Swatches[0].Name)
+	}
+	for i, s := range palette.Swatches {
+		line := fmt.Sprintf("%s (%s) %.0f%%", s.Name, s.Color.Hex(), s.Weight*100)
+		if s.Role != "" {
+			line += " · " + s.Role
+		}
+		if swatches[i].OutOfGamut >= outOfGamutShare {
+			line += " · beyond sRGB"
+		}
+		text += line + "\n"
 	}
 
 	post, err := client.NewPostBuilder().
@@ -254,6 +301,89 @@ func (b *Bot) GenerateAndPostFromBing(ctx context.Context) error {
 	return nil
 }
 
+// GenerateAndPostFromAnimation generates a palette from every frame of an
+// animated GIF and posts it along with a timeline of each frame's colors
+func (b *Bot) GenerateAndPostFromAnimation(ctx context.Context, url string) error {
+	// Ensure we have a valid session before proceeding
+	if err := b.RefreshSession(ctx); err != nil {
+		return fmt.Errorf("failed to refresh session: %w", err)
+	}
+
+	anim, err := getAnimation(ctx, url)
+	if err != nil {
+		return fmt.Errorf("failed to get animation: %w", err)
+	}
+
+	// Sample sparsely; many frames make up for it
+	opts := color.ExtractOptions{NumColors: 5, Stride: 2}
+	clusters := color.ExtractSequenceClusters(anim.Frames, opts)
+	if len(clusters) == 0 {
+		// Every frame was empty or fully transparent
+		return fmt.Errorf("no colors found in animation")
+	}
+
+	total := 0.0
+	for _, c := range clusters {
+		total += c.Population
+	}
+
+	palette := color.Palette{Source: url, Created: time.Now().UTC()}
+	for _, c := range clusters {
+		palette.Swatches = append(palette.Swatches, color.Swatch{
+			Color:  c.Color,
+			Name:   b.matcher.NameColor(c.Color),
+			Weight: c.Population / total,
+		})
+	}
+
+	paletteImg, err := color.GeneratePaletteImage(color.NewPaletteImage(palette))
+	if err != nil {
+		return fmt.Errorf("failed to generate palette image: %w", err)
+	}
+
+	timelineImg, err := color.GenerateTimelineImage(color.TimelineImage{
+		Palettes: color.FramePalettes(anim.Frames, opts),
+		Delays:   anim.Delays,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to generate timeline image: %w", err)
+	}
+
+	var uploaded []models.UploadedImage
+	for _, img := range []image.Image{paletteImg, timelineImg} {
+		u, err := b.uploadImage(ctx, img)
+		if err != nil {
+			return fmt.Errorf("failed to upload image: %w", err)
+		}
+		uploaded = append(uploaded, *u)
+	}
+
+	// Create post text
+	text := fmt.Sprintf("🎞️ Palette across %d frames\n\n", len(anim.Frames))
+	for _, s := range palette.Swatches {
+		text += fmt.Sprintf("%s (%s) %.0f%%\n", s.Name, s.Color.Hex(), s.Weight*100)
+	}
+
+	post, err := client.NewPostBuilder().
+		AddText(text).
+		AddTag("Color").
+		AddTag("Animation").
+		AddTag("Design").
+		WithImages(uploaded).
+		Build()
+	if err != nil {
+		return fmt.Errorf("failed to create post: %w", err)
+	}
+
+	// Post to Bluesky
+	_, _, err = b.client.PostToFeed(ctx, post)
+	if err != nil {
+		return fmt.Errorf("failed to post to feed: %w", err)
+	}
+
+	return nil
+}
+
 func (b *Bot) uploadImage(ctx context.Context, img image.Image) (*models.UploadedImage, error) {
 	buf := new(bytes.Buffer)
 
@@ -275,23 +405,3 @@ func (b *Bot) uploadImage(ctx context.Context, img image.Image) (*models.Uploade
 
 	return uploadedImage, nil
 }
-
-// getPaletteTypeName returns a human-readable name for the palette type
-func (b *Bot) getPaletteTypeName(pt color.PaletteType) string {
-	switch pt {
-	case color.Complementary:
-		return "Complementary"
-	case color.Triadic:
-		return "Triadic"
-	case color.Analogous:
-		return "Analogous"
-	case color.SplitComplementary:
-		return "Split Complementary"
-	case color.Tetradic:
-		return "Tetradic"
-	case color.Monochromatic:
-		return "Monochromatic"
-	default:
-		return "Unknown"
-	}
-}
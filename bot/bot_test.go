@@ -0,0 +1,98 @@
+package bot
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+
+	"github.com/watzon/pigmentpoet/color"
+)
+
+func hexPalette(t *testing.T, hexes ...string) color.Palette {
+	t.Helper()
+	var p color.Palette
+	for _, hex := range hexes {
+		c, err := color.ParseColor(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Swatches = append(p.Swatches, color.Swatch{Color: c})
+	}
+	return p
+}
+
+func TestMinPaletteScoreSeparates(t *testing.T) {
+	good := [][]string{
+		{"#264653", "#2A9D8F", "#E9C46A", "#F4A261", "#E76F51"},
+		{"#1D3557", "#457B9D", "#A8DADC", "#F1FAEE", "#E63946"},
+		{"#003049", "#D62828", "#F77F00", "#FCBF49", "#EAE2B7"},
+		{"#2B2D42", "#8D99AE", "#EDF2F4", "#EF233C", "#D90429"},
+	}
+	bad := map[string][]string{
+		"near-duplicates": {"#3366CC", "#3467CD", "#3265CB", "#3568CE", "#3164CA"},
+		"muddy":           {"#6B6B5E", "#7A7466", "#5E6B62", "#736B70", "#6E7060"},
+		"mid grays":       {"#808080", "#858585", "#7A7A7A", "#8A8A8A", "#777777"},
+		"near black":      {"#101010", "#141018", "#181410", "#0C1414", "#121212"},
+		"dusty pastels":   {"#9A8F7C", "#8C9A7C", "#7C8C9A", "#9A7C8C", "#8F8F8F"},
+	}
+
+	for _, hexes := range good {
+		if s := color.ScorePalette(hexPalette(t, hexes...)); s.Total < DefaultMinPaletteScore {
+			t.Errorf("good palette %v scored %s", hexes, s)
+		}
+	}
+	for name, hexes := range bad {
+		if s := color.ScorePalette(hexPalette(t, hexes...)); s.Total >= DefaultMinPaletteScore {
+			t.Errorf("%s palette %v scored %s", name, hexes, s)
+		}
+	}
+}
+
+func TestMinPaletteScoreRandomPalettes(t *testing.T) {
+	m, err := color.NewPreloadedColorMatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Palettes drawn the way generatePalette draws them
+	rng := rand.New(rand.NewSource(1))
+	const n = 400
+	var passed, failed []color.PaletteScore
+	for i := 0; i < n; i++ {
+		base := fmt.Sprintf("#%02X%02X%02X", rng.Intn(256), rng.Intn(256), rng.Intn(256))
+		pt := color.PaletteType(rng.Intn(int(color.NeutralAccent) + 1))
+		p, err := m.NewGeneratedPalette(base, pt, 5, color.WithColorSpace(color.SpaceOKLCH))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s := color.ScorePalette(p); s.Total >= DefaultMinPaletteScore {
+			passed = append(passed, s)
+		} else {
+			failed = append(failed, s)
+		}
+	}
+
+	// The threshold should reject a real share of palettes without making
+	// generatePalette give up
+	if share := float64(len(passed)) / n; share < 0.15 || share > 0.8 {
+		t.Errorf("%.0f%% of random palettes pass", share*100)
+	}
+
+	mean := func(scores []color.PaletteScore, criterion func(color.PaletteScore) float64) float64 {
+		total := 0.0
+		for _, s := range scores {
+			total += criterion(s)
+		}
+		return total / float64(len(scores))
+	}
+	criteria := map[string]func(color.PaletteScore) float64{
+		"distinctness":     func(s color.PaletteScore) float64 { return s.Distinctness },
+		"lightness spread": func(s color.PaletteScore) float64 { return s.LightnessSpread },
+		"contrast":         func(s color.PaletteScore) float64 { return s.ContrastAvailability },
+	}
+	for name, criterion := range criteria {
+		if p, f := mean(passed, criterion), mean(failed, criterion); p <= f {
+			t.Errorf("passing palettes average %.2f on %s, rejected ones %.2f", p, name, f)
+		}
+	}
+}
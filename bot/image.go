@@ -2,9 +2,12 @@ package bot
 
 import (
 	"image"
+	"image/color"
 	"math"
+	"sync"
 
 	"github.com/nfnt/resize"
+	pigment "github.com/watzon/pigmentpoet/color"
 )
 
 const (
@@ -32,6 +35,88 @@ func resizeImage(img image.Image) image.Image {
 	newWidth := uint(float64(width) * ratio)
 	newHeight := uint(float64(height) * ratio)
 
-	// Resize using Lanczos resampling
-	return resize.Resize(newWidth, newHeight, img, resize.Lanczos3)
+	resized := resizeLinear(newWidth, newHeight, img)
+
+	// Keep track of the pixels clipped when converting from the image's
+	// color profile
+	if converted, ok := img.(*pigment.ConvertedImage); ok {
+		return converted.Resized(resized)
+	}
+	return resized
+}
+
+// resizeLinear resizes with Lanczos resampling in linear light. Filtering
+// gamma-encoded values darkens fine detail such as foliage and text edges.
+func resizeLinear(width, height uint, img image.Image) *image.NRGBA {
+	return fromLinear(resize.Resize(width, height, toLinear(img), resize.Lanczos3))
+}
+
+var (
+	gammaTablesOnce sync.Once
+	// decodeTable maps 16-bit sRGB values to 16-bit linear light
+	decodeTable []uint16
+	// encodeTable maps 16-bit linear light to 16-bit sRGB values
+	encodeTable []uint16
+)
+
+func gammaTables() {
+	gammaTablesOnce.Do(func() {
+		decodeTable = make([]uint16, 1<<16)
+		encodeTable = make([]uint16, 1<<16)
+		for i := range decodeTable {
+			v := float64(i) / 0xffff
+
+			lin := v / 12.92
+			if v > 0.04045 {
+				lin = math.Pow((v+0.055)/1.055, 2.4)
+			}
+			decodeTable[i] = uint16(math.Round(lin * 0xffff))
+
+			enc := v * 12.92
+			if v > 0.0031308 {
+				enc = 1.055*math.Pow(v, 1/2.4) - 0.055
+			}
+			encodeTable[i] = uint16(math.Round(enc * 0xffff))
+		}
+	})
+}
+
+// toLinear converts an image to premultiplied linear-light RGBA64
+func toLinear(img image.Image) *image.RGBA64 {
+	gammaTables()
+
+	bounds := img.Bounds()
+	out := image.NewRGBA64(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBA64Model.Convert(img.At(x, y)).(color.NRGBA64)
+			premul := func(v uint16) uint16 {
+				return uint16(uint32(decodeTable[v]) * uint32(c.A) / 0xffff)
+			}
+			out.SetRGBA64(x, y, color.RGBA64{R: premul(c.R), G: premul(c.G), B: premul(c.B), A: c.A})
+		}
+	}
+	return out
+}
+
+// fromLinear converts a premultiplied linear-light image back to sRGB
+func fromLinear(img image.Image) *image.NRGBA {
+	gammaTables()
+
+	bounds := img.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			if a == 0 {
+				continue
+			}
+			encode := func(v uint32) uint8 {
+				e := uint32(encodeTable[min(v*0xffff/a, 0xffff)])
+				return uint8((e*0xff + 0x7fff) / 0xffff)
+			}
+			out.SetNRGBA(x, y, color.NRGBA{R: encode(r), G: encode(g), B: encode(b), A: uint8(a >> 8)})
+		}
+	}
+	return out
 }
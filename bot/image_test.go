@@ -0,0 +1,97 @@
+package bot
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+func TestResizeLinearCheckerboard(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x+y)%2 == 0 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+
+	// Half black and half white light is #BCBCBC, not the #808080 that
+	// filtering gamma-encoded values gives
+	out := resizeLinear(8, 8, img)
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			c := color.NRGBAModel.Convert(out.At(x, y)).(color.NRGBA)
+			for _, v := range []uint8{c.R, c.G, c.B} {
+				if v < 186 || v > 190 {
+					t.Fatalf("pixel (%d, %d) is %v, want about 188", x, y, c)
+				}
+			}
+			if c.A != 255 {
+				t.Fatalf("pixel (%d, %d) has alpha %d, want 255", x, y, c.A)
+			}
+		}
+	}
+}
+
+func TestResizeLinearGradient(t *testing.T) {
+	// A ramp from black to white with every other column black, downscaled 16
+	// times. Each output column mixes dark and light pixels, so filtering
+	// gamma-encoded values would come out visibly too dark.
+	const (
+		width = 512
+		scale = 16
+	)
+	level := func(x int) uint8 {
+		if x%2 == 0 {
+			return 0
+		}
+		return uint8(x * 255 / (width - 1))
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, width, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < width; x++ {
+			v := level(x)
+			img.SetNRGBA(x, y, color.NRGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	out := resizeLinear(width/scale, 1, img)
+	prev := -1
+	// The outermost columns are left out, as the filter runs off the edge there
+	for x := 1; x < width/scale-1; x++ {
+		// Each output column should be the mean light of its source window
+		mean := 0.0
+		for sx := x * scale; sx < (x+1)*scale; sx++ {
+			mean += srgbToLinear(float64(level(sx))/255) / scale
+		}
+		want := linearToSRGB(mean) * 255
+
+		got := int(color.NRGBAModel.Convert(out.At(x, 0)).(color.NRGBA).R)
+		if math.Abs(float64(got)-want) > 2 {
+			t.Errorf("column %d is %d, want about %.1f", x, got, want)
+		}
+		if got < prev {
+			t.Errorf("column %d is %d, darker than the column before at %d", x, got, prev)
+		}
+		prev = got
+	}
+}
@@ -4,7 +4,6 @@ import (
 	_ "embed"
 	"encoding/json"
 	"fmt"
-	"image"
 	"math"
 	"sort"
 )
@@ -35,71 +34,244 @@ type HSL struct {
 // ColorMatcher handles color matching and analysis
 type ColorMatcher struct {
 	colors []ColorName
-	// Cache RGB and HSL values for performance
-	colorCache map[string]struct {
-		RGB Color
-		HSL HSL
+	metric DistanceMetric
+	// Matches farther than this are flagged as approximate
+	maxDistance float64
+	// Cache perceptual coordinates for performance, indexed like colors
+	colorCache []cachedColor
+	// Spatial index over the cached coordinates in the metric's color space
+	tree *kdTree
+	// Largest CIELAB chroma among the named colors, which bounds CIEDE2000
+	// tree searches
+	maxChroma float64
+}
+
+// cachedColor holds the precomputed coordinates of a named color
+type cachedColor struct {
+	RGB   Color
+	Lab   Lab
+	OKLab OKLab
+}
+
+// ColorMatch is a named color along with its distance from the queried color
+type ColorMatch struct {
+	ColorName
+	// Distance is measured with the matcher's metric; lower is better
+	Distance float64 `json:"distance"`
+	// Confidence normalizes Distance into (0, 1], where 1 is an exact match
+	Confidence float64 `json:"confidence"`
+	// Approximate is set when the match is too far away to describe the color honestly
+	Approximate bool `json:"approximate"`
+}
+
+// MatcherOption configures a ColorMatcher
+type MatcherOption func(*ColorMatcher)
+
+// WithMetric sets the distance metric used to rank candidate names
+func WithMetric(metric DistanceMetric) MatcherOption {
+	return func(m *ColorMatcher) {
+		m.metric = metric
+	}
+}
+
+// WithMaxDistance sets the distance beyond which matches are flagged as approximate.
+// The default depends on the metric.
+func WithMaxDistance(distance float64) MatcherOption {
+	return func(m *ColorMatcher) {
+		m.maxDistance = distance
+	}
+}
+
+// defaultMaxDistance returns a distance at which two colors clearly read as different
+func defaultMaxDistance(metric DistanceMetric) float64 {
+	switch metric {
+	case CIE76:
+		return 10
+	case OKLabEuclidean:
+		return 0.06
+	default:
+		return 6
 	}
 }
 
 // PreloadedColorMatcher returns a new ColorMatcher with the embedded colors
-func NewPreloadedColorMatcher() (*ColorMatcher, error) {
-	return NewColorMatcher(colorData)
+func NewPreloadedColorMatcher(opts ...MatcherOption) (*ColorMatcher, error) {
+	return NewColorMatcher(colorData, opts...)
 }
 
-// NewColorMatcher creates a new color matcher from JSON data
-func NewColorMatcher(jsonData []byte) (*ColorMatcher, error) {
+// NewColorMatcher creates a new color matcher from JSON data.
+// Colors are ranked by CIEDE2000 unless another metric is selected.
+func NewColorMatcher(jsonData []byte, opts ...MatcherOption) (*ColorMatcher, error) {
 	var colors []ColorName
 	if err := json.Unmarshal(jsonData, &colors); err != nil {
 		return nil, fmt.Errorf("failed to parse color data: %w", err)
 	}
 
 	matcher := &ColorMatcher{
-		colors: colors,
-		colorCache: make(map[string]struct {
-			RGB Color
-			HSL HSL
-		}),
+		colors:     colors,
+		metric:     CIEDE2000,
+		colorCache: make([]cachedColor, len(colors)),
+	}
+	for _, opt := range opts {
+		opt(matcher)
+	}
+	if matcher.maxDistance <= 0 {
+		matcher.maxDistance = defaultMaxDistance(matcher.metric)
 	}
 
-	// Initialize cache
-	for _, c := range colors {
-		rgb := hexToRGB(c.Hex)
-		hsl := rgbToHSL(rgb)
-		matcher.colorCache[c.Hex] = struct {
-			RGB Color
-			HSL HSL
-		}{RGB: rgb, HSL: hsl}
+	// Initialize cache and index
+	points := make([][3]float64, len(colors))
+	for i, c := range colors {
+		matcher.colorCache[i] = newCachedColor(hexToRGB(c.Hex))
+		points[i] = matcher.point(matcher.colorCache[i])
+		lab := matcher.colorCache[i].Lab
+		matcher.maxChroma = math.Max(matcher.maxChroma, math.Hypot(lab.A, lab.B))
 	}
+	matcher.tree = newKDTree(points)
 
 	return matcher, nil
 }
 
-// FindClosestColor finds the closest named color to the given hex color
+func newCachedColor(rgb Color) cachedColor {
+	return cachedColor{
+		RGB:   rgb,
+		Lab:   rgb.ToLab(),
+		OKLab: rgb.ToOKLab(),
+	}
+}
+
+// Metric returns the distance metric used by the matcher
+func (m *ColorMatcher) Metric() DistanceMetric {
+	return m.metric
+}
+
+// FindClosestColor finds the closest named color to the given hex color.
+// Malformed input is read as black; use FindClosestColorStrict to get an error instead.
 func (m *ColorMatcher) FindClosestColor(hex string) (ColorName, error) {
-	targetRGB := hexToRGB(hex)
-	targetHSL := rgbToHSL(targetRGB)
+	if len(m.colors) == 0 {
+		return ColorName{}, fmt.Errorf("no colors loaded")
+	}
+	return m.closest(hexToRGB(hex), 1)[0].ColorName, nil
+}
 
-	var closest ColorName
-	minDiff := math.MaxFloat64
+// FindClosestColorStrict finds the closest named color to any color ParseColor accepts
+func (m *ColorMatcher) FindClosestColorStrict(s string) (ColorName, error) {
+	match, err := m.MatchColor(s)
+	if err != nil {
+		return ColorName{}, err
+	}
+	return match.ColorName, nil
+}
 
-	for _, c := range m.colors {
-		cached := m.colorCache[c.Hex]
+// MatchColor finds the closest named color to the given color string and
+// reports how far away it is
+func (m *ColorMatcher) MatchColor(s string) (ColorMatch, error) {
+	matches, err := m.FindClosestColors(s, 1)
+	if err != nil {
+		return ColorMatch{}, err
+	}
+	return matches[0], nil
+}
 
-		// Calculate color difference using a combination of RGB and HSL
-		rgbDiff := colorDifferenceRGB(targetRGB, cached.RGB)
-		hslDiff := colorDifferenceHSL(targetHSL, cached.HSL)
+// FindClosestColors returns the n closest named colors to the given color
+// string, ranked from best to worst
+func (m *ColorMatcher) FindClosestColors(s string, n int) ([]ColorMatch, error) {
+	if n < 1 {
+		return nil, fmt.Errorf("invalid number of matches: %d", n)
+	}
+	if len(m.colors) == 0 {
+		return nil, fmt.Errorf("no colors loaded")
+	}
+
+	target, err := ParseColor(s)
+	if err != nil {
+		return nil, err
+	}
 
-		// Weight HSL difference more heavily as it better matches human perception
-		totalDiff := rgbDiff + 2*hslDiff
+	return m.closest(target, n), nil
+}
+
+// closest returns the n named colors nearest to target
+func (m *ColorMatcher) closest(target Color, n int) []ColorMatch {
+	cached := newCachedColor(target)
 
-		if totalDiff < minDiff {
-			minDiff = totalDiff
-			closest = c
+	var neighbors []kdNeighbor
+	switch m.metric {
+	case CIE76, OKLabEuclidean:
+		// Euclidean in the indexed space, so the tree order is exact
+		neighbors = m.tree.nearest(m.point(cached), n)
+	default:
+		neighbors = m.tree.nearestBy(m.point(cached), n, m.searchScale(cached), func(_ [3]float64, i int) float64 {
+			return m.distance(cached, m.colorCache[i])
+		})
+	}
+	matches := make([]ColorMatch, len(neighbors))
+	for i, nb := range neighbors {
+		diff := m.distance(cached, m.colorCache[nb.index])
+		matches[i] = ColorMatch{
+			ColorName:   m.colors[nb.index],
+			Distance:    diff,
+			Confidence:  math.Exp(-diff / m.maxDistance),
+			Approximate: diff > m.maxDistance,
 		}
 	}
 
-	return closest, nil
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Distance < matches[j].Distance
+	})
+	if len(matches) > n {
+		matches = matches[:n]
+	}
+
+	return matches
+}
+
+// point returns the coordinates a color is indexed by for the matcher's metric
+func (m *ColorMatcher) point(c cachedColor) [3]float64 {
+	if m.metric == OKLabEuclidean {
+		return [3]float64{c.OKLab.L, c.OKLab.A, c.OKLab.B}
+	}
+	return [3]float64{c.Lab.L, c.Lab.A, c.Lab.B}
+}
+
+// maxSL2000 is the largest lightness weighting CIEDE2000 applies, reached at
+// mean lightness 0 or 100
+var maxSL2000 = 1 + 0.015*2500/math.Sqrt(20+2500)
+
+// searchScale returns how far the matcher's metric must be, at least, per
+// unit of CIELAB difference along L, a and b from the target. Tree searches
+// use it to skip subtrees without missing a match.
+func (m *ColorMatcher) searchScale(target cachedColor) [3]float64 {
+	chroma := math.Hypot(target.Lab.A, target.Lab.B)
+	switch m.metric {
+	case CIE94:
+		// SL is 1 and SH never exceeds SC, which depends on the target alone
+		sC := 1 + 0.045*chroma
+		return [3]float64{1, 1 / sC, 1 / sC}
+	case CIEDE2000:
+		// The chroma and hue terms together are at least the ab distance over
+		// the largest SC either color allows, with a' stretching a by at most
+		// 1.5. The rotation term takes away at most sin(60°) of them.
+		maxSC := 1 + 0.045*1.5*(chroma+m.maxChroma)/2
+		ab := math.Sqrt(1-math.Sin(math.Pi/3)) / maxSC
+		return [3]float64{1 / maxSL2000, ab, ab}
+	default:
+		return [3]float64{1, 1, 1}
+	}
+}
+
+// distance measures how far a candidate is from the target using the matcher's metric
+func (m *ColorMatcher) distance(target, candidate cachedColor) float64 {
+	switch m.metric {
+	case CIE76:
+		return deltaE76(target.Lab, candidate.Lab)
+	case CIE94:
+		return deltaE94(target.Lab, candidate.Lab)
+	case OKLabEuclidean:
+		return deltaOK(target.OKLab, candidate.OKLab)
+	default:
+		return deltaE2000(target.Lab, candidate.Lab)
+	}
 }
 
 // Helper functions for color conversion and comparison
@@ -124,9 +296,9 @@ func rgbToHSL(rgb Color) HSL {
 
 	if max != min {
 		d := max - min
-		s = d / (2 - max - min)
+		s = d / (max + min)
 		if l > 0.5 {
-			s = d / (2 - (max + min))
+			s = d / (2 - max - min)
 		}
 
 		switch max {
@@ -146,117 +318,6 @@ func rgbToHSL(rgb Color) HSL {
 	return HSL{H: h * 360, S: s * 100, L: l * 100}
 }
 
-func colorDifferenceRGB(c1, c2 Color) float64 {
-	return math.Pow(float64(c1.R)-float64(c2.R), 2) +
-		math.Pow(float64(c1.G)-float64(c2.G), 2) +
-		math.Pow(float64(c1.B)-float64(c2.B), 2)
-}
-
-func colorDifferenceHSL(c1, c2 HSL) float64 {
-	return math.Pow(c1.H-c2.H, 2) +
-		math.Pow(c1.S-c2.S, 2) +
-		math.Pow(c1.L-c2.L, 2)
-}
-
-// Palette extraction functionality
-type Box struct {
-	rMin, rMax, gMin, gMax, bMin, bMax int
-	colors                             []Color
-}
-
-func (b *Box) volume() int {
-	return (b.rMax - b.rMin + 1) * (b.gMax - b.gMin + 1) * (b.bMax - b.bMin + 1)
-}
-
-// ExtractPalette extracts a color palette from an image
-func ExtractPalette(img image.Image, numColors int) []Color {
-	if numColors < 2 {
-		numColors = 2
-	}
-	if numColors > 256 {
-		numColors = 256
-	}
-
-	// Convert image to color slice
-	var colors []Color
-	bounds := img.Bounds()
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			r, g, b, a := img.At(x, y).RGBA()
-			if a > 0 {
-				colors = append(colors, Color{
-					R: uint8(r >> 8),
-					G: uint8(g >> 8),
-					B: uint8(b >> 8),
-				})
-			}
-		}
-	}
-
-	// Create initial box containing all colors
-	box := createBox(colors)
-	boxes := []*Box{box}
-
-	// Extract more colors than needed to account for filtering
-	targetColors := numColors * 2
-
-	// Split boxes until we have desired number of colors
-	for len(boxes) < targetColors {
-		boxToSplit := findBoxToSplit(boxes)
-		if boxToSplit == nil {
-			break
-		}
-		box1, box2 := splitBox(boxToSplit)
-		boxes = append(boxes[:len(boxes)-1], box1, box2)
-	}
-
-	// Extract average color from each box
-	palette := make([]Color, len(boxes))
-	for i, box := range boxes {
-		palette[i] = averageColor(box.colors)
-	}
-
-	// Filter similar colors with a threshold of 60 (adjust this value as needed)
-	palette = filterSimilarColors(palette, 60.0)
-
-	// If we have more colors than requested, take the first numColors
-	if len(palette) > numColors {
-		palette = palette[:numColors]
-	}
-
-	return palette
-}
-
-// colorDistance calculates the Euclidean distance between two colors in RGB space
-func colorDistance(c1, c2 Color) float64 {
-	rDiff := float64(c1.R) - float64(c2.R)
-	gDiff := float64(c1.G) - float64(c2.G)
-	bDiff := float64(c1.B) - float64(c2.B)
-	return math.Sqrt(rDiff*rDiff + gDiff*gDiff + bDiff*bDiff)
-}
-
-// filterSimilarColors removes colors that are too similar to each other
-func filterSimilarColors(colors []Color, threshold float64) []Color {
-	if len(colors) <= 1 {
-		return colors
-	}
-
-	result := []Color{colors[0]}
-	for i := 1; i < len(colors); i++ {
-		isDistinct := true
-		for _, existing := range result {
-			if dist := colorDistance(colors[i], existing); dist < threshold {
-				isDistinct = false
-				break
-			}
-		}
-		if isDistinct {
-			result = append(result, colors[i])
-		}
-	}
-	return result
-}
-
 // PaletteType represents different types of color palettes
 type PaletteType int
 
@@ -267,29 +328,116 @@ const (
 	SplitComplementary
 	Tetradic
 	Monochromatic
+	Square
+	DoubleComplementary
+	Compound
+	Shades
+	Tints
+	Tones
+	NeutralAccent
 )
 
-// GeneratePalette creates a palette of colors based on a base color and palette type
-func (m *ColorMatcher) GeneratePalette(baseHex string, paletteType PaletteType, variations int) []Color {
-	baseColor := hexToRGB(baseHex)
-	baseHSL := rgbToHSL(baseColor)
+// String returns a human-readable name for the palette type
+func (pt PaletteType) String() string {
+	switch pt {
+	case Complementary:
+		return "Complementary"
+	case Triadic:
+		return "Triadic"
+	case Analogous:
+		return "Analogous"
+	case SplitComplementary:
+		return "Split Complementary"
+	case Tetradic:
+		return "Tetradic"
+	case Monochromatic:
+		return "Monochromatic"
+	case Square:
+		return "Square"
+	case DoubleComplementary:
+		return "Double Complementary"
+	case Compound:
+		return "Compound"
+	case Shades:
+		return "Shades"
+	case Tints:
+		return "Tints"
+	case Tones:
+		return "Tones"
+	case NeutralAccent:
+		return "Neutral Accent"
+	default:
+		return "Unknown"
+	}
+}
 
+// MaxPaletteSize is the largest number of colors a generated palette can have
+const MaxPaletteSize = 32
+
+// GeneratePalette creates a palette of colors based on a base color and palette type.
+// A malformed base color is read as black and an invalid number of variations
+// returns nil.
+//
+// Deprecated: GeneratePalette hides invalid input. Use GeneratePaletteStrict,
+// which returns an error instead.
+func (m *ColorMatcher) GeneratePalette(baseHex string, paletteType PaletteType, variations int, opts ...PaletteOption) []Color {
+	colors, err := m.generatePalette(hexToRGB(baseHex), paletteType, variations, opts)
+	if err != nil {
+		return nil
+	}
+	return colors
+}
+
+// GeneratePaletteStrict creates a palette of the given number of colors from
+// any base color ParseColor accepts
+func (m *ColorMatcher) GeneratePaletteStrict(base string, paletteType PaletteType, variations int, opts ...PaletteOption) ([]Color, error) {
+	baseColor, err := ParseColor(base)
+	if err != nil {
+		return nil, err
+	}
+	return m.generatePalette(baseColor, paletteType, variations, opts)
+}
+
+func (m *ColorMatcher) generatePalette(baseColor Color, paletteType PaletteType, variations int, opts []PaletteOption) ([]Color, error) {
+	if variations < 1 || variations > MaxPaletteSize {
+		return nil, fmt.Errorf("invalid number of colors %d: must be between 1 and %d", variations, MaxPaletteSize)
+	}
+
+	cfg := newPaletteConfig(opts)
+
+	var tones []tone
 	switch paletteType {
 	case Complementary:
-		return m.complementaryPalette(baseHSL)
+		tones = m.complementaryPalette(variations)
 	case Triadic:
-		return m.triadicPalette(baseHSL)
+		tones = m.triadicPalette(variations)
 	case Analogous:
-		return m.analogousPalette(baseHSL, variations)
+		tones = m.analogousPalette(variations)
 	case SplitComplementary:
-		return m.splitComplementaryPalette(baseHSL)
+		tones = m.splitComplementaryPalette(variations)
 	case Tetradic:
-		return m.tetradicPalette(baseHSL)
+		tones = m.tetradicPalette(variations)
 	case Monochromatic:
-		return m.monochromaticPalette(baseHSL, variations)
+		tones = m.monochromaticPalette(variations)
+	case Square:
+		tones = m.squarePalette(variations)
+	case DoubleComplementary:
+		tones = m.doubleComplementaryPalette(variations)
+	case Compound:
+		tones = m.compoundPalette(variations)
+	case Shades:
+		tones = m.shadesPalette(variations)
+	case Tints:
+		tones = m.tintsPalette(variations)
+	case Tones:
+		tones = m.tonesPalette(variations)
+	case NeutralAccent:
+		tones = m.neutralAccentPalette(variations)
 	default:
-		return []Color{baseColor}
+		return nil, fmt.Errorf("unknown palette type: %d", paletteType)
 	}
+
+	return renderTones(baseColor, tones, cfg.space), nil
 }
 
 // Helper function to rotate hue
@@ -304,8 +452,9 @@ func rotateHue(hsl HSL, degrees float64) HSL {
 // Convert HSL back to RGB
 func hslToRGB(hsl HSL) Color {
 	h := hsl.H / 360
-	s := hsl.S / 100
-	l := hsl.L / 100
+	// Clamp so out-of-range inputs saturate instead of wrapping around uint8
+	s := clamp(hsl.S, 0, 100) / 100
+	l := clamp(hsl.L, 0, 100) / 100
 
 	var r, g, b float64
 
@@ -354,231 +503,153 @@ func hueToRGB(p, q, t float64) float64 {
 }
 
 // Palette generation methods
-func (m *ColorMatcher) complementaryPalette(base HSL) []Color {
-	complement := rotateHue(base, 180)
-	// Create variations between base and complement
-	color2 := HSL{
-		H: base.H,
-		S: base.S * 0.8,
-		L: base.L * 1.2,
-	}
-	color3 := HSL{
-		H: complement.H,
-		S: complement.S * 0.8,
-		L: complement.L * 1.2,
-	}
-	color4 := HSL{
-		H: base.H,
-		S: base.S * 0.6,
-		L: base.L * 1.4,
-	}
-	return []Color{
-		hslToRGB(base),
-		hslToRGB(color2),
-		hslToRGB(color3),
-		hslToRGB(color4),
-		hslToRGB(complement),
-	}
-}
-
-func (m *ColorMatcher) triadicPalette(base HSL) []Color {
-	color2 := rotateHue(base, 120)
-	color3 := rotateHue(base, 240)
-	// Add intermediate colors
-	color4 := rotateHue(base, 60)
-	color5 := rotateHue(base, 180)
-	return []Color{
-		hslToRGB(base),
-		hslToRGB(color4),
-		hslToRGB(color2),
-		hslToRGB(color5),
-		hslToRGB(color3),
-	}
-}
-
-func (m *ColorMatcher) analogousPalette(base HSL, variations int) []Color {
-	angleStep := 15.0
-	colors := make([]Color, 5)
-	colors[0] = hslToRGB(base)
-	
-	// Two colors clockwise
-	colors[1] = hslToRGB(rotateHue(base, -angleStep))
-	colors[2] = hslToRGB(rotateHue(base, -angleStep*2))
-	
-	// Two colors counterclockwise
-	colors[3] = hslToRGB(rotateHue(base, angleStep))
-	colors[4] = hslToRGB(rotateHue(base, angleStep*2))
-	
-	return colors
+func (m *ColorMatcher) complementaryPalette(variations int) []tone {
+	return harmonyTones([]float64{0, 180}, variations)
 }
 
-func (m *ColorMatcher) splitComplementaryPalette(base HSL) []Color {
-	complement := rotateHue(base, 180)
-	split1 := rotateHue(complement, -30)
-	split2 := rotateHue(complement, 30)
-	// Add intermediate colors
-	color4 := HSL{
-		H: base.H,
-		S: base.S * 0.8,
-		L: base.L * 1.2,
-	}
-	color5 := HSL{
-		H: complement.H,
-		S: complement.S * 0.8,
-		L: complement.L * 1.2,
-	}
-	return []Color{
-		hslToRGB(base),
-		hslToRGB(color4),
-		hslToRGB(split1),
-		hslToRGB(split2),
-		hslToRGB(color5),
-	}
-}
-
-func (m *ColorMatcher) tetradicPalette(base HSL) []Color {
-	color2 := rotateHue(base, 90)
-	color3 := rotateHue(base, 180)
-	color4 := rotateHue(base, 270)
-	// Add intermediate color
-	color5 := HSL{
-		H: base.H,
-		S: base.S * 0.8,
-		L: base.L * 1.2,
-	}
-	return []Color{
-		hslToRGB(base),
-		hslToRGB(color2),
-		hslToRGB(color3),
-		hslToRGB(color4),
-		hslToRGB(color5),
-	}
-}
-
-func (m *ColorMatcher) monochromaticPalette(base HSL, variations int) []Color {
-	colors := make([]Color, 5)
-	colors[0] = hslToRGB(base)
-
-	// Two lighter shades
-	colors[1] = hslToRGB(HSL{
-		H: base.H,
-		S: math.Max(0, base.S*0.8),
-		L: math.Min(100, base.L*1.2),
-	})
-	colors[2] = hslToRGB(HSL{
-		H: base.H,
-		S: math.Max(0, base.S*0.6),
-		L: math.Min(100, base.L*1.4),
-	})
-
-	// Two darker shades
-	colors[3] = hslToRGB(HSL{
-		H: base.H,
-		S: math.Min(100, base.S*1.2),
-		L: math.Max(0, base.L*0.8),
-	})
-	colors[4] = hslToRGB(HSL{
-		H: base.H,
-		S: math.Min(100, base.S*1.4),
-		L: math.Max(0, base.L*0.6),
-	})
-
-	return colors
+func (m *ColorMatcher) triadicPalette(variations int) []tone {
+	return harmonyTones([]float64{0, 120, 240}, variations)
 }
 
-func createBox(colors []Color) *Box {
-	if len(colors) == 0 {
-		return &Box{}
+func (m *ColorMatcher) analogousPalette(variations int) []tone {
+	// Neighbours alternate around the base, spreading at most 60 degrees each way
+	perSide := variations / 2
+	angleStep := 15.0
+	if perSide > 4 {
+		angleStep = 60.0 / float64(perSide)
 	}
 
-	box := &Box{
-		rMin: 255, rMax: 0,
-		gMin: 255, gMax: 0,
-		bMin: 255, bMax: 0,
-		colors: colors,
+	tones := []tone{{0, 1, 1}}
+	for step := 1; len(tones) < variations; step++ {
+		offset := angleStep * float64(step)
+		// Clockwise first, then counterclockwise
+		tones = append(tones, tone{-offset, 1, 1})
+		if len(tones) < variations {
+			tones = append(tones, tone{offset, 1, 1})
+		}
 	}
+	return tones
+}
 
-	for _, c := range colors {
-		box.rMin = min(box.rMin, int(c.R))
-		box.rMax = max(box.rMax, int(c.R))
-		box.gMin = min(box.gMin, int(c.G))
-		box.gMax = max(box.gMax, int(c.G))
-		box.bMin = min(box.bMin, int(c.B))
-		box.bMax = max(box.bMax, int(c.B))
-	}
+func (m *ColorMatcher) splitComplementaryPalette(variations int) []tone {
+	return harmonyTones([]float64{0, 150, 210}, variations)
+}
 
-	return box
+func (m *ColorMatcher) tetradicPalette(variations int) []tone {
+	// Two complementary pairs 60 degrees apart, forming a rectangle
+	return harmonyTones([]float64{0, 60, 180, 240}, variations)
 }
 
-func findBoxToSplit(boxes []*Box) *Box {
-	var maxBox *Box
-	maxVolume := 0
+func (m *ColorMatcher) monochromaticPalette(variations int) []tone {
+	return harmonyTones([]float64{0}, variations)
+}
 
-	for _, box := range boxes {
-		volume := box.volume()
-		if volume > maxVolume {
-			maxVolume = volume
-			maxBox = box
-		}
-	}
+func (m *ColorMatcher) squarePalette(variations int) []tone {
+	// Four hues evenly spaced around the color wheel
+	return harmonyTones([]float64{0, 90, 180, 270}, variations)
+}
 
-	return maxBox
+func (m *ColorMatcher) doubleComplementaryPalette(variations int) []tone {
+	// The base and its neighbour, each with its complement: a narrow rectangle
+	return harmonyTones([]float64{0, 30, 180, 210}, variations)
 }
 
-func splitBox(box *Box) (*Box, *Box) {
-	// Find longest dimension
-	rRange := box.rMax - box.rMin
-	gRange := box.gMax - box.gMin
-	bRange := box.bMax - box.bMin
+func (m *ColorMatcher) compoundPalette(variations int) []tone {
+	// The base with an analogous neighbour, and the complement with its
+	// neighbour. The neighbour comes first so small palettes are not the
+	// same as double complementary ones.
+	return harmonyTones([]float64{0, 30, 150, 180}, variations)
+}
 
-	var dim byte
-	switch {
-	case rRange >= gRange && rRange >= bRange:
-		dim = 'r'
-	case gRange >= rRange && gRange >= bRange:
-		dim = 'g'
-	default:
-		dim = 'b'
-	}
-
-	// Sort colors along chosen dimension
-	sort.Slice(box.colors, func(i, j int) bool {
-		switch dim {
-		case 'r':
-			return box.colors[i].R < box.colors[j].R
-		case 'g':
-			return box.colors[i].G < box.colors[j].G
-		default:
-			return box.colors[i].B < box.colors[j].B
-		}
-	})
+func (m *ColorMatcher) shadesPalette(variations int) []tone {
+	// Progressively mix the base toward black
+	tones := make([]tone, variations)
+	for i := range tones {
+		tones[i] = tone{0, 1, 1 - rampAmount(i, variations)}
+	}
+	return tones
+}
 
-	// Split at median
-	median := len(box.colors) / 2
-	box1 := createBox(box.colors[:median])
-	box2 := createBox(box.colors[median:])
+func (m *ColorMatcher) tintsPalette(variations int) []tone {
+	// Progressively mix the base toward white; a lightness factor of 1/(1-t)
+	// closes fraction t of the distance to white
+	tones := make([]tone, variations)
+	for i := range tones {
+		tones[i] = tone{0, 1, 1 / (1 - rampAmount(i, variations))}
+	}
+	return tones
+}
 
-	return box1, box2
+func (m *ColorMatcher) tonesPalette(variations int) []tone {
+	// Progressively mix the base toward gray
+	tones := make([]tone, variations)
+	for i := range tones {
+		tones[i] = tone{0, 1 - rampAmount(i, variations), 1}
+	}
+	return tones
 }
 
-func averageColor(colors []Color) Color {
-	if len(colors) == 0 {
-		return Color{}
+func (m *ColorMatcher) neutralAccentPalette(variations int) []tone {
+	// The base is the accent; everything else is a near-neutral of its hue
+	tones := []tone{{0, 1, 1}}
+	for _, t := range variationTones(0, variations-1) {
+		t.saturation = 0.1
+		tones = append(tones, t)
 	}
+	return tones
+}
 
-	var rSum, gSum, bSum int
-	for _, c := range colors {
-		rSum += int(c.R)
-		gSum += int(c.G)
-		bSum += int(c.B)
+// rampAmount spreads step i of n evenly over [0, 0.85], stopping short of a
+// full mix so the last color keeps some of the base
+func rampAmount(i, n int) float64 {
+	if n < 2 {
+		return 0
 	}
+	return 0.85 * float64(i) / float64(n-1)
+}
 
-	count := len(colors)
-	return Color{
-		R: uint8(rSum / count),
-		G: uint8(gSum / count),
-		B: uint8(bSum / count),
+// harmonyTones returns n tones built around the given hue anchors. Each anchor
+// appears once, and any remaining slots are shared out as tints and shades of
+// the anchors, grouped after the anchor they belong to.
+func harmonyTones(anchors []float64, n int) []tone {
+	if n <= len(anchors) {
+		tones := make([]tone, n)
+		for i := range tones {
+			tones[i] = tone{anchors[i], 1, 1}
+		}
+		return tones
+	}
+
+	extras := n - len(anchors)
+	tones := make([]tone, 0, n)
+	for i, hue := range anchors {
+		count := extras / len(anchors)
+		if i < extras%len(anchors) {
+			count++
+		}
+		tones = append(tones, tone{hue, 1, 1})
+		tones = append(tones, variationTones(hue, count)...)
+	}
+	return tones
+}
+
+// variationTones returns count lighter and darker versions of a hue, lighter
+// ones first. Steps shrink as the count grows so the spread stays bounded.
+func variationTones(hue float64, count int) []tone {
+	lighter := (count + 1) / 2
+	darker := count / 2
+	step := 0.4 / float64(max(lighter, 1))
+
+	tones := make([]tone, 0, count)
+	for k := 1; k <= lighter; k++ {
+		amount := step * float64(k)
+		tones = append(tones, tone{hue, 1 - amount, 1 + amount})
+	}
+	for k := 1; k <= darker; k++ {
+		amount := step * float64(k)
+		tones = append(tones, tone{hue, 1 + amount, 1 - amount})
 	}
+	return tones
 }
 
 func min(a, b int) int {
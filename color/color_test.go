@@ -0,0 +1,188 @@
+package color
+
+import (
+	"errors"
+	"math"
+	"math/rand"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var allMetrics = []DistanceMetric{CIE76, CIE94, CIEDE2000, OKLabEuclidean}
+
+// bruteForceClosest scans every named color with the matcher's metric, the
+// way lookups worked before the k-d tree
+func bruteForceClosest(m *ColorMatcher, target Color, n int) []ColorMatch {
+	cached := newCachedColor(target)
+	var best []ColorMatch
+	for i := range m.colors {
+		d := m.distance(cached, m.colorCache[i])
+		if len(best) == n && d >= best[n-1].Distance {
+			continue
+		}
+		pos := sort.Search(len(best), func(j int) bool { return best[j].Distance > d })
+		if len(best) < n {
+			best = append(best, ColorMatch{})
+		}
+		copy(best[pos+1:], best[pos:])
+		best[pos] = ColorMatch{ColorName: m.colors[i], Distance: d}
+	}
+	return best
+}
+
+func randomColors(n int, seed int64) []Color {
+	rng := rand.New(rand.NewSource(seed))
+	colors := make([]Color, n)
+	for i := range colors {
+		colors[i] = Color{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256))}
+	}
+	return colors
+}
+
+func TestClosestMatchesBruteForce(t *testing.T) {
+	// Random colors plus the corners of the RGB cube and the grays, where
+	// CIE94 and CIEDE2000 differ most from Euclidean CIELAB
+	targets := randomColors(2000, 1)
+	for _, v := range []uint8{0, 255} {
+		for _, w := range []uint8{0, 255} {
+			for _, x := range []uint8{0, 255} {
+				targets = append(targets, Color{v, w, x})
+			}
+		}
+	}
+	for v := 0; v < 256; v += 5 {
+		targets = append(targets, Color{uint8(v), uint8(v), uint8(v)})
+	}
+
+	for _, metric := range allMetrics {
+		t.Run(metric.String(), func(t *testing.T) {
+			m, err := NewPreloadedColorMatcher(WithMetric(metric))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for _, target := range targets {
+				const n = 3
+				got := m.closest(target, n)
+				want := bruteForceClosest(m, target, n)
+				for i := range want {
+					// Equally distant names may come back in either order
+					if math.Abs(got[i].Distance-want[i].Distance) > 1e-9 {
+						t.Fatalf("%s match %d: got %s at %v, want %s at %v",
+							target.Hex(), i, got[i].Name, got[i].Distance, want[i].Name, want[i].Distance)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRGBToHSL(t *testing.T) {
+	tests := []struct {
+		c    Color
+		want HSL
+	}{
+		{Color{0, 0, 0}, HSL{0, 0, 0}},
+		{Color{255, 255, 255}, HSL{0, 0, 100}},
+		{Color{255, 0, 0}, HSL{0, 100, 50}},
+		{Color{0x33, 0x66, 0xCC}, HSL{220, 60, 50}},
+		// Dark colors, where saturation is d/(max+min)
+		{Color{0x80, 0, 0}, HSL{0, 100, 25.1}},
+		{Color{0x33, 0x19, 0x19}, HSL{0, 34.2, 14.9}},
+		{Color{0x1A, 0x33, 0x4D}, HSL{210.6, 49.5, 20.2}},
+		// Light colors, where saturation is d/(2-max-min)
+		{Color{0xCC, 0xE6, 0xFF}, HSL{209.4, 100, 90}},
+		{Color{0xE6, 0xCC, 0xCC}, HSL{0, 34.2, 85.1}},
+	}
+	for _, tt := range tests {
+		got := rgbToHSL(tt.c)
+		if math.Abs(got.H-tt.want.H) > 0.1 || math.Abs(got.S-tt.want.S) > 0.1 || math.Abs(got.L-tt.want.L) > 0.1 {
+			t.Errorf("%s: got %+v, want %+v", tt.c.Hex(), got, tt.want)
+		}
+	}
+
+	for _, c := range randomColors(2000, 6) {
+		hsl := rgbToHSL(c)
+		if hsl.S < 0 || hsl.S > 100 {
+			t.Errorf("%s: saturation %v out of range", c.Hex(), hsl.S)
+		}
+		if got := hslToRGB(hsl); got != c {
+			t.Errorf("%s: HSL round trip gave %s", c.Hex(), got.Hex())
+		}
+	}
+}
+
+func TestGeneratePaletteStrictErrors(t *testing.T) {
+	m, err := NewPreloadedColorMatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, n := range []int{-1, 0, MaxPaletteSize + 1} {
+		if colors, err := m.GeneratePaletteStrict("#3366CC", Triadic, n); err == nil {
+			t.Errorf("%d colors: got %v, want an error", n, colors)
+		}
+	}
+	if _, err := m.GeneratePaletteStrict("#33", Triadic, 5); !errors.Is(err, ErrInvalidColor) {
+		t.Errorf("malformed base: got %v, want ErrInvalidColor", err)
+	}
+	if _, err := m.GeneratePaletteStrict("#3366CC", PaletteType(-1), 5); err == nil {
+		t.Error("unknown palette type: got no error")
+	}
+	for _, n := range []int{1, 3, 7, 12, MaxPaletteSize} {
+		if colors, err := m.GeneratePaletteStrict("#3366CC", Triadic, n); err != nil || len(colors) != n {
+			t.Errorf("%d colors: got %d colors and %v", n, len(colors), err)
+		}
+	}
+}
+
+func TestPaletteTypesDistinct(t *testing.T) {
+	m, err := NewPreloadedColorMatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	base := Color{R: 0x33, G: 0x66, B: 0xCC}
+	for _, size := range []int{3, 4, 5, 8} {
+		seen := make(map[string]PaletteType)
+		for pt := Complementary; pt <= NeutralAccent; pt++ {
+			colors, err := m.GeneratePaletteStrict(base.Hex(), pt, size)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// Compare as sets, so a reordering of the same colors still counts
+			hexes := make([]string, len(colors))
+			for i, c := range colors {
+				hexes[i] = c.Hex()
+			}
+			sort.Strings(hexes)
+			key := strings.Join(hexes, " ")
+			if other, ok := seen[key]; ok {
+				t.Errorf("%s and %s give the same %d-color palette", other, pt, size)
+			}
+			seen[key] = pt
+		}
+	}
+}
+
+func BenchmarkFindClosestColor(b *testing.B) {
+	targets := randomColors(1024, 2)
+	for _, metric := range allMetrics {
+		m, err := NewPreloadedColorMatcher(WithMetric(metric))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(metric.String()+"/tree", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m.closest(targets[i%len(targets)], 1)
+			}
+		})
+		b.Run(metric.String()+"/brute", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				bruteForceClosest(m, targets[i%len(targets)], 1)
+			}
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package color
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// ErrUnsatisfiable is returned, wrapped with details, when FillPalette cannot
+// find colors that meet its constraints
+var ErrUnsatisfiable = errors.New("palette constraints cannot be satisfied")
+
+// Constraints limit the colors FillPalette generates. The zero value imposes
+// no constraints.
+type Constraints struct {
+	// MinDeltaE is the smallest CIEDE2000 difference allowed between a
+	// generated color and any other color in the palette
+	MinDeltaE float64
+	// MinLightness and MaxLightness bound the lightness of generated colors,
+	// on the [0, 1] scale of the palette's color space. A MaxLightness of 0
+	// means no upper bound.
+	MinLightness float64
+	MaxLightness float64
+	// MinContrast is the WCAG contrast ratio that at least one pair of colors
+	// must reach, so the palette has a usable text and background pair
+	MinContrast float64
+}
+
+// lightnessSteps is how many evenly spaced lightness levels are tried for
+// each hue when the harmony alone cannot meet the constraints
+const lightnessSteps = 10
+
+// lightnessTolerance absorbs 8-bit rounding when checking the lightness range
+const lightnessTolerance = 0.01
+
+// FillPalette keeps the locked colors and generates the rest of a palette of
+// the given size around them, following the palette type's harmony with the
+// first locked color as its base. Locked colors come first, in order, and are
+// never changed. Options select the color space as for GeneratePaletteStrict.
+func (m *ColorMatcher) FillPalette(locked []Color, paletteType PaletteType, size int, cons Constraints, opts ...PaletteOption) (Palette, error) {
+	if len(locked) == 0 {
+		return Palette{}, fmt.Errorf("at least one locked color is required")
+	}
+	if size < len(locked) || size > MaxPaletteSize {
+		return Palette{}, fmt.Errorf("invalid number of colors %d: must be between %d and %d", size, len(locked), MaxPaletteSize)
+	}
+
+	space := newPaletteConfig(opts).space
+	maxL := cons.MaxLightness
+	if maxL == 0 {
+		maxL = 1
+	}
+	if cons.MinLightness > maxL {
+		return Palette{}, fmt.Errorf("%w: lightness range %.2f to %.2f is empty", ErrUnsatisfiable, cons.MinLightness, maxL)
+	}
+
+	pool, err := m.fillCandidates(locked, paletteType, size, space, cons.MinLightness, maxL)
+	if err != nil {
+		return Palette{}, err
+	}
+
+	chosen := append([]Color(nil), locked...)
+	var picked []int // Indices into pool, so generated colors keep harmony order
+
+	fits := func(i int) bool {
+		c := pool[i]
+		if l := toPolar(c, space).L; l < cons.MinLightness-lightnessTolerance || l > maxL+lightnessTolerance {
+			return false
+		}
+		lab := c.ToLab()
+		for _, other := range chosen {
+			if other == c || deltaE2000(lab, other.ToLab()) < cons.MinDeltaE {
+				return false
+			}
+		}
+		return true
+	}
+	pick := func(i int) {
+		chosen = append(chosen, pool[i])
+		picked = append(picked, i)
+	}
+
+	// Reserve a slot for a contrasting color first, when the locked colors
+	// do not already include a contrasting pair
+	if cons.MinContrast > 0 && !hasContrast(chosen, cons.MinContrast) {
+		if size == len(locked) {
+			return Palette{}, fmt.Errorf("%w: no pair of locked colors reaches contrast %.1f", ErrUnsatisfiable, cons.MinContrast)
+		}
+		found := false
+		for i, c := range pool {
+			if fits(i) && contrastsWithAny(c, chosen, cons.MinContrast) {
+				pick(i)
+				found = true
+				break
+			}
+		}
+		// Otherwise the pair has to come from two generated colors
+		if !found && size-len(locked) >= 2 {
+			found = pickContrastingPair(pool, cons.MinContrast, cons.MinDeltaE, fits, pick)
+		}
+		if !found {
+			return Palette{}, fmt.Errorf("%w: no pair of colors reaches contrast %.1f", ErrUnsatisfiable, cons.MinContrast)
+		}
+	}
+
+	for i := range pool {
+		if len(chosen) == size {
+			break
+		}
+		if fits(i) {
+			pick(i)
+		}
+	}
+	if len(chosen) < size {
+		return Palette{}, fmt.Errorf("%w: found only %d of %d colors at least %.1f ΔE apart", ErrUnsatisfiable, len(chosen), size, cons.MinDeltaE)
+	}
+
+	sort.Ints(picked)
+	base := locked[0]
+	p := Palette{
+		Swatches: make([]Swatch, 0, size),
+		Source:   SourceGenerated,
+		Type:     &paletteType,
+		Base:     &base,
+		Created:  time.Now().UTC(),
+	}
+	for _, c := range locked {
+		p.Swatches = append(p.Swatches, Swatch{Color: c, Name: m.NameColor(c)})
+	}
+	for _, i := range picked {
+		p.Swatches = append(p.Swatches, Swatch{Color: pool[i], Name: m.NameColor(pool[i])})
+	}
+	return p, nil
+}
+
+// fillCandidates lists the colors FillPalette may pick from, most preferred
+// first: the harmony around each locked color at the requested size, then at
+// larger sizes with more tints and shades, then every one of those colors at
+// evenly spaced lightness levels across the allowed range
+func (m *ColorMatcher) fillCandidates(locked []Color, paletteType PaletteType, size int, space ColorSpace, minL, maxL float64) ([]Color, error) {
+	seen := make(map[Color]bool)
+	for _, c := range locked {
+		seen[c] = true
+	}
+
+	var pool []Color
+	add := func(c Color) {
+		if !seen[c] {
+			seen[c] = true
+			pool = append(pool, c)
+		}
+	}
+
+	opts := []PaletteOption{WithColorSpace(space)}
+	for _, base := range locked {
+		for n := max(size, 2); n <= MaxPaletteSize; n *= 2 {
+			colors, err := m.generatePalette(base, paletteType, n, opts)
+			if err != nil {
+				return nil, err
+			}
+			for _, c := range colors {
+				add(c)
+			}
+		}
+	}
+
+	hues := append(append([]Color(nil), pool...), locked...)
+	for step := 0; step <= lightnessSteps; step++ {
+		l := minL + (maxL-minL)*float64(step)/lightnessSteps
+		for _, c := range hues {
+			add(c.Transform(space, NormalizeLightness(l)))
+		}
+	}
+	return pool, nil
+}
+
+// pickContrastingPair picks the most preferred pair of fitting candidates
+// that reach the contrast ratio and stay minDeltaE apart from each other
+func pickContrastingPair(pool []Color, minContrast, minDeltaE float64, fits func(int) bool, pick func(int)) bool {
+	var candidates []int
+	luminance := make([]float64, len(pool))
+	for i, c := range pool {
+		if fits(i) {
+			candidates = append(candidates, i)
+			luminance[i] = RelativeLuminance(c)
+		}
+	}
+
+	for n, i := range candidates {
+		for _, j := range candidates[n+1:] {
+			lighter, darker := math.Max(luminance[i], luminance[j]), math.Min(luminance[i], luminance[j])
+			if (lighter+0.05)/(darker+0.05) < minContrast {
+				continue
+			}
+			if deltaE2000(pool[i].ToLab(), pool[j].ToLab()) < minDeltaE {
+				continue
+			}
+			pick(i)
+			pick(j)
+			return true
+		}
+	}
+	return false
+}
+
+// hasContrast reports whether any pair of colors reaches the contrast ratio
+func hasContrast(colors []Color, minContrast float64) bool {
+	for i, c := range colors {
+		if contrastsWithAny(c, colors[i+1:], minContrast) {
+			return true
+		}
+	}
+	return false
+}
+
+func contrastsWithAny(c Color, others []Color, minContrast float64) bool {
+	best := 0.0
+	for _, other := range others {
+		best = math.Max(best, ContrastRatio(c, other))
+	}
+	return best >= minContrast
+}
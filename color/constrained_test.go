@@ -0,0 +1,115 @@
+package color
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestFillPalette(t *testing.T) {
+	m, err := NewPreloadedColorMatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	locked := []Color{{0x33, 0x66, 0xCC}, {0xF2, 0xC1, 0x4E}}
+	constraints := []Constraints{
+		{},
+		{MinDeltaE: 15},
+		{MinLightness: 0.3, MaxLightness: 0.8},
+		{MinContrast: 7},
+		{MinDeltaE: 10, MinLightness: 0.2, MaxLightness: 0.9, MinContrast: 4.5},
+	}
+
+	for _, space := range []ColorSpace{SpaceHSL, SpaceOKLCH} {
+		for _, pt := range []PaletteType{Complementary, Analogous, Tetradic, Monochromatic, Shades} {
+			for _, cons := range constraints {
+				name := fmt.Sprintf("%v/%v/%+v", space, pt, cons)
+				t.Run(name, func(t *testing.T) {
+					p, err := m.FillPalette(locked, pt, 6, cons, WithColorSpace(space))
+					if err != nil {
+						t.Fatal(err)
+					}
+					checkFilledPalette(t, p, locked, 6, cons, space)
+				})
+			}
+		}
+	}
+}
+
+// checkFilledPalette checks that a FillPalette result keeps the locked colors
+// first and that every generated color meets the constraints
+func checkFilledPalette(t *testing.T, p Palette, locked []Color, size int, cons Constraints, space ColorSpace) {
+	t.Helper()
+	colors := p.Colors()
+	if len(colors) != size {
+		t.Fatalf("got %d colors, want %d", len(colors), size)
+	}
+	for i, c := range locked {
+		if colors[i] != c {
+			t.Errorf("slot %d: got %s, want locked %s", i, colors[i].Hex(), c.Hex())
+		}
+	}
+
+	maxL := cons.MaxLightness
+	if maxL == 0 {
+		maxL = 1
+	}
+	for i := len(locked); i < len(colors); i++ {
+		c := colors[i]
+		if l := toPolar(c, space).L; l < cons.MinLightness-lightnessTolerance || l > maxL+lightnessTolerance {
+			t.Errorf("%s: lightness %.3f outside %.2f to %.2f", c.Hex(), l, cons.MinLightness, maxL)
+		}
+		for j, other := range colors {
+			if j == i {
+				continue
+			}
+			if other == c {
+				t.Errorf("%s appears twice", c.Hex())
+			}
+			if d := deltaE2000(c.ToLab(), other.ToLab()); d < cons.MinDeltaE {
+				t.Errorf("%s and %s are only %.1f ΔE apart", c.Hex(), other.Hex(), d)
+			}
+		}
+	}
+	if cons.MinContrast > 0 && !hasContrast(colors, cons.MinContrast) {
+		t.Errorf("no pair of colors reaches contrast %.1f", cons.MinContrast)
+	}
+}
+
+func TestFillPaletteUnsatisfiable(t *testing.T) {
+	m, err := NewPreloadedColorMatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gray := Color{0x80, 0x80, 0x80}
+	tests := []struct {
+		name   string
+		locked []Color
+		size   int
+		cons   Constraints
+	}{
+		{"colors too far apart", []Color{gray}, 12, Constraints{MinDeltaE: 60}},
+		{"empty lightness range", []Color{gray}, 4, Constraints{MinLightness: 0.7, MaxLightness: 0.3}},
+		{"contrast beyond black on white", []Color{gray}, 4, Constraints{MinContrast: 22}},
+		{"contrast in a narrow lightness band", []Color{gray}, 4, Constraints{MinLightness: 0.45, MaxLightness: 0.55, MinContrast: 7}},
+		{"locked colors without contrast and no free slot", []Color{gray, {0x90, 0x90, 0x90}}, 2, Constraints{MinContrast: 4.5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := m.FillPalette(tt.locked, Analogous, tt.size, tt.cons)
+			if !errors.Is(err, ErrUnsatisfiable) {
+				t.Errorf("got %v with colors %v, want ErrUnsatisfiable", err, p.Colors())
+			}
+		})
+	}
+
+	// Bad arguments are plain errors
+	if _, err := m.FillPalette(nil, Analogous, 4, Constraints{}); err == nil || errors.Is(err, ErrUnsatisfiable) {
+		t.Errorf("no locked colors: got %v", err)
+	}
+	if _, err := m.FillPalette([]Color{gray, gray}, Analogous, 1, Constraints{}); err == nil || errors.Is(err, ErrUnsatisfiable) {
+		t.Errorf("size below locked count: got %v", err)
+	}
+}
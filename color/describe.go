@@ -0,0 +1,57 @@
+package color
+
+import (
+	"math"
+	"strings"
+)
+
+// hueNames splits the HSL hue circle into 30° sectors centred on each name
+var hueNames = []string{
+	"Red", "Orange", "Yellow", "Lime", "Green", "Spring Green",
+	"Cyan", "Azure", "Blue", "Violet", "Magenta", "Rose",
+}
+
+// DescribeColor returns a plain descriptive name such as "Dark Muted Blue".
+// It is used when no named color is close enough to be honest.
+func DescribeColor(c Color) string {
+	lab := c.ToLab()
+	chroma := math.Hypot(lab.A, lab.B)
+
+	var words []string
+
+	switch {
+	case lab.L < 25:
+		words = append(words, "Dark")
+	case lab.L > 80:
+		words = append(words, "Light")
+	}
+
+	// Nearly neutral colors are described as grays
+	if chroma < 8 {
+		switch {
+		case lab.L < 10:
+			return "Black"
+		case lab.L > 95:
+			return "White"
+		}
+		return strings.Join(append(words, "Gray"), " ")
+	}
+
+	switch {
+	case chroma < 25:
+		words = append(words, "Muted")
+	case chroma > 70:
+		words = append(words, "Vivid")
+	}
+
+	hsl := rgbToHSL(c)
+	sector := int(math.Mod(hsl.H+15, 360) / 30)
+	hue := hueNames[sector]
+
+	// Dark oranges read as browns
+	if hue == "Orange" && lab.L < 50 {
+		hue = "Brown"
+	}
+
+	return strings.Join(append(words, hue), " ")
+}
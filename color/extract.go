@@ -0,0 +1,332 @@
+package color
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// WeightedColor is a distinct color along with how many pixels carry it
+type WeightedColor struct {
+	Color
+	Weight float64
+}
+
+// Cluster is a group of similar pixels found by an Extractor
+type Cluster struct {
+	Color Color
+	// Population is the total weight of the pixels in the cluster, which is
+	// the pixel count for plain extraction
+	Population float64
+}
+
+// Extractor quantizes a color histogram into at most k clusters
+type Extractor interface {
+	Extract(colors []WeightedColor, k int) []Cluster
+}
+
+// ExtractOptions configures palette extraction
+type ExtractOptions struct {
+	// NumColors is the number of colors to extract, clamped to [2, 256]
+	NumColors int
+	// Extractor is the quantization algorithm; MedianCut is used when nil
+	Extractor Extractor
+
+	// Stride reads every Stride-th pixel along each axis; 0 or 1 reads them all
+	Stride int
+	// SampleSize caps how many pixels are read, choosing them by reservoir
+	// sampling; 0 reads every pixel selected by Stride
+	SampleSize int
+	// Workers is the number of goroutines reading pixels; 0 uses GOMAXPROCS.
+	// Each worker keeps its own fixed-size histogram.
+	Workers int
+
+	// MinAlpha skips pixels less opaque than this. Fully transparent pixels
+	// are always skipped, and the rest count in proportion to their alpha.
+	MinAlpha uint8
+
+	// The options below shift the palette toward the subject of a photo. Each
+	// is a strength from 0, which disables it, to 1.
+
+	// SuppressBackground down-weights flat regions connected to the image
+	// border, such as skies and letterboxing
+	SuppressBackground float64
+	// SuppressClipped down-weights near-black and near-white pixels
+	SuppressClipped float64
+	// Saliency weights pixels by how much their area stands out from its
+	// surroundings
+	Saliency float64
+	// CenterPrior weights pixels by their closeness to the image center
+	CenterPrior float64
+}
+
+// minColorDistance is how far apart, in RGB, extracted colors should be
+const minColorDistance = 60.0
+
+// ExtractPalette extracts a color palette from an image. It returns numColors
+// colors ordered by population, unless the image has fewer distinct colors.
+func ExtractPalette(img image.Image, numColors int) []Color {
+	return clusterColors(ExtractClusters(img, ExtractOptions{NumColors: numColors}))
+}
+
+// ExtractClusters extracts a palette using the given options and reports the
+// population of each color. Pixels from clusters that were too similar to keep
+// are counted toward the nearest kept cluster and blended into its color.
+func ExtractClusters(img image.Image, opts ExtractOptions) []Cluster {
+	return clusterHistogram(collectColors(img, opts), opts)
+}
+
+// clusterHistogram quantizes a color histogram with the extractor and color
+// count in opts, keeping only clusters that are distinct enough
+func clusterHistogram(colors []WeightedColor, opts ExtractOptions) []Cluster {
+	numColors := opts.NumColors
+	if numColors < 2 {
+		numColors = 2
+	}
+	if numColors > 256 {
+		numColors = 256
+	}
+
+	extractor := opts.Extractor
+	if extractor == nil {
+		extractor = MedianCut{}
+	}
+
+	if len(colors) == 0 {
+		return nil
+	}
+
+	// Extract more colors than needed to account for filtering
+	clusters := extractor.Extract(colors, numColors*2)
+
+	// Most populous clusters first
+	sortClusters(clusters)
+
+	return filterSimilarClusters(clusters, minColorDistance, numColors)
+}
+
+// collectColors builds an alpha-weighted histogram of the sampled pixels in an image
+func collectColors(img image.Image, opts ExtractOptions) []WeightedColor {
+	histograms := make([]*histogram, numWorkers(opts))
+	for i := range histograms {
+		histograms[i] = new(histogram)
+	}
+
+	sampleImage(img, opts, func(worker, x, y int, c Color, weight float64) {
+		histograms[worker].add(c, weight)
+	})
+
+	for _, h := range histograms[1:] {
+		histograms[0].merge(h)
+	}
+	return histograms[0].colors()
+}
+
+// colorDistance calculates the Euclidean distance between two colors in RGB space
+func colorDistance(c1, c2 Color) float64 {
+	rDiff := float64(c1.R) - float64(c2.R)
+	gDiff := float64(c1.G) - float64(c2.G)
+	bDiff := float64(c1.B) - float64(c2.B)
+	return math.Sqrt(rDiff*rDiff + gDiff*gDiff + bDiff*bDiff)
+}
+
+// filterSimilarClusters picks up to n clusters, in order, whose colors are at
+// least threshold apart. If that leaves fewer than n, it backfills with the
+// earliest skipped clusters that are not exact duplicates. Every cluster left
+// out is merged into the nearest picked one, whose color becomes the
+// population-weighted mean of both, so the result does not depend on which of
+// two similar clusters happened to be picked.
+func filterSimilarClusters(clusters []Cluster, threshold float64, n int) []Cluster {
+	var result, skipped []Cluster
+	for _, c := range clusters {
+		isDistinct := len(result) < n
+		for _, existing := range result {
+			if !isDistinct {
+				break
+			}
+			if dist := colorDistance(c.Color, existing.Color); dist < threshold {
+				isDistinct = false
+			}
+		}
+		if isDistinct {
+			result = append(result, c)
+		} else {
+			skipped = append(skipped, c)
+		}
+	}
+
+	var leftover []Cluster
+	for _, c := range skipped {
+		if len(result) < n && !containsColor(clusterColors(result), c.Color) {
+			result = append(result, c)
+		} else {
+			leftover = append(leftover, c)
+		}
+	}
+
+	resultColors := clusterColors(result)
+	means := make([]linearMean, len(result))
+	for i, c := range result {
+		means[i].add(c.Color, c.Population)
+	}
+	for _, c := range leftover {
+		means[nearestColor(c.Color, resultColors)].add(c.Color, c.Population)
+	}
+	for i := range result {
+		result[i] = Cluster{Color: means[i].color(), Population: means[i].weight}
+	}
+
+	sortClusters(result)
+	return result
+}
+
+func containsColor(colors []Color, c Color) bool {
+	for _, existing := range colors {
+		if existing == c {
+			return true
+		}
+	}
+	return false
+}
+
+// sortClusters orders clusters from most to least populous
+func sortClusters(clusters []Cluster) {
+	sort.SliceStable(clusters, func(i, j int) bool {
+		return clusters[i].Population > clusters[j].Population
+	})
+}
+
+// clusterColors returns the color of each cluster
+func clusterColors(clusters []Cluster) []Color {
+	colors := make([]Color, len(clusters))
+	for i, c := range clusters {
+		colors[i] = c.Color
+	}
+	return colors
+}
+
+// ImageSwatch is an extracted color along with how much of the image it
+// covers and where
+type ImageSwatch struct {
+	Color Color
+	// Fraction is the alpha-weighted share of pixels nearest to this color
+	Fraction float64
+	// Centroid is the mean position of those pixels
+	Centroid image.Point
+	// Spread is the root-mean-square distance of those pixels from the
+	// centroid, relative to the image diagonal
+	Spread float64
+	// Location is a pixel that shows the color: the one nearest the centroid
+	// within the largest connected region of the color
+	Location image.Point
+	// OutOfGamut is the share of those pixels that lay outside sRGB in the
+	// image's color profile and were clipped, so the color is approximate
+	OutOfGamut float64
+}
+
+// ExtractPaletteDetailed extracts a palette and measures each color's pixel
+// coverage, position and spread by assigning every sampled pixel to its
+// nearest color. Swatches are ordered by coverage.
+func ExtractPaletteDetailed(img image.Image, opts ExtractOptions) []ImageSwatch {
+	clusters := ExtractClusters(img, opts)
+	if len(clusters) == 0 {
+		return nil
+	}
+	colors := clusterColors(clusters)
+
+	type accumulator struct {
+		count, sumX, sumY, sumSq, clipped float64
+	}
+	type workerState struct {
+		acc     []accumulator
+		nearest map[Color]int
+	}
+
+	states := make([]workerState, numWorkers(opts))
+	for i := range states {
+		states[i] = workerState{
+			acc:     make([]accumulator, len(colors)),
+			nearest: make(map[Color]int),
+		}
+	}
+
+	gamut, _ := img.(gamutReporter)
+	sampleImage(img, opts, func(worker, x, y int, c Color, weight float64) {
+		state := &states[worker]
+		idx, ok := state.nearest[c]
+		if !ok {
+			idx = nearestColor(c, colors)
+			state.nearest[c] = idx
+		}
+
+		fx, fy := float64(x), float64(y)
+		a := &state.acc[idx]
+		a.count += weight
+		a.sumX += fx * weight
+		a.sumY += fy * weight
+		a.sumSq += (fx*fx + fy*fy) * weight
+		if gamut != nil && gamut.OutOfGamut(x, y) {
+			a.clipped += weight
+		}
+	})
+
+	// Combine the per-worker sums
+	acc := states[0].acc
+	total := 0.0
+	for _, state := range states[1:] {
+		for i, a := range state.acc {
+			acc[i].count += a.count
+			acc[i].sumX += a.sumX
+			acc[i].sumY += a.sumY
+			acc[i].sumSq += a.sumSq
+			acc[i].clipped += a.clipped
+		}
+	}
+	for _, a := range acc {
+		total += a.count
+	}
+
+	bounds := img.Bounds()
+	diagonal := math.Hypot(float64(bounds.Dx()), float64(bounds.Dy()))
+	swatches := make([]ImageSwatch, 0, len(colors))
+	for i, c := range colors {
+		a := acc[i]
+		if a.count == 0 {
+			continue
+		}
+		meanX, meanY := a.sumX/a.count, a.sumY/a.count
+		variance := math.Max(0, a.sumSq/a.count-meanX*meanX-meanY*meanY)
+		swatches = append(swatches, ImageSwatch{
+			Color:      c,
+			Fraction:   a.count / total,
+			Centroid:   image.Pt(int(math.Round(meanX)), int(math.Round(meanY))),
+			Spread:     math.Sqrt(variance) / diagonal,
+			OutOfGamut: a.clipped / a.count,
+		})
+	}
+
+	swatchColors := make([]Color, len(swatches))
+	centroids := make([]image.Point, len(swatches))
+	for i, s := range swatches {
+		swatchColors[i], centroids[i] = s.Color, s.Centroid
+	}
+	for i, loc := range representativeLocations(img, opts, swatchColors, centroids) {
+		swatches[i].Location = loc
+	}
+
+	sort.SliceStable(swatches, func(i, j int) bool {
+		return swatches[i].Fraction > swatches[j].Fraction
+	})
+	return swatches
+}
+
+// nearestColor returns the index of the palette color closest to c
+func nearestColor(c Color, palette []Color) int {
+	nearest := 0
+	for i := 1; i < len(palette); i++ {
+		if colorDistance(c, palette[i]) < colorDistance(c, palette[nearest]) {
+			nearest = i
+		}
+	}
+	return nearest
+}
@@ -0,0 +1,134 @@
+package color
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// regionsImage returns a w x h image of noisy color regions of unequal size,
+// like a simple photo with a few dominant colors
+func regionsImage(w, h int, seed int64) *image.NRGBA {
+	rng := rand.New(rand.NewSource(seed))
+	noisy := func(r, g, b int) color.NRGBA {
+		jitter := func(v int) uint8 {
+			return uint8(clamp(float64(v+rng.Intn(25)-12), 0, 255))
+		}
+		return color.NRGBA{R: jitter(r), G: jitter(g), B: jitter(b), A: 255}
+	}
+
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var c color.NRGBA
+			switch {
+			case y < h/4:
+				c = noisy(90, 160, 225) // Sky
+			case x < w/3:
+				c = noisy(200, 50, 40)
+			case x < w/2 && y < h/2:
+				c = noisy(240, 220, 60)
+			case y > 4*h/5:
+				c = noisy(40, 40, 50)
+			case x > 3*w/4:
+				c = noisy(50, 150, 70)
+			default:
+				c = noisy(230, 225, 210)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// worstMatch returns the largest CIEDE2000 difference between a cluster in a
+// and the nearest cluster in b
+func worstMatch(a, b []Cluster) float64 {
+	worst := 0.0
+	for _, x := range a {
+		nearest := math.Inf(1)
+		for _, y := range b {
+			nearest = math.Min(nearest, deltaE2000(x.Color.ToLab(), y.Color.ToLab()))
+		}
+		worst = math.Max(worst, nearest)
+	}
+	return worst
+}
+
+func TestSampledExtractionMatchesFullScan(t *testing.T) {
+	// Largest CIEDE2000 difference allowed between a swatch and the nearest
+	// swatch from the full scan
+	const tolerance = 3.0
+
+	tests := []ExtractOptions{
+		{Workers: 4},
+		{Stride: 2},
+		{Stride: 3, Workers: 4},
+		{SampleSize: 2000},
+		{SampleSize: 2000, Workers: 4},
+		{SampleSize: 10000, Workers: 3},
+		{Stride: 2, SampleSize: 5000, Workers: 4},
+	}
+
+	for seed := int64(1); seed <= 3; seed++ {
+		img := regionsImage(300, 300, seed)
+		full := ExtractClusters(img, ExtractOptions{NumColors: 6, Workers: 1})
+
+		for _, opts := range tests {
+			opts.NumColors = 6
+			name := fmt.Sprintf("seed %d stride %d sample %d workers %d", seed, opts.Stride, opts.SampleSize, opts.Workers)
+			t.Run(name, func(t *testing.T) {
+				got := ExtractClusters(img, opts)
+				if len(got) != len(full) {
+					t.Fatalf("got %d colors, want %d", len(got), len(full))
+				}
+				if d := math.Max(worstMatch(full, got), worstMatch(got, full)); d > tolerance {
+					t.Errorf("swatches differ by ΔE %.1f from the full scan\ngot  %v\nwant %v", d, got, full)
+				}
+			})
+		}
+	}
+}
+
+func TestExtractionWorkersDeterministic(t *testing.T) {
+	img := regionsImage(300, 300, 1)
+	for _, opts := range []ExtractOptions{{}, {Stride: 3}, {SampleSize: 2000}} {
+		opts.NumColors = 6
+		opts.Workers = 1
+		want := ExtractClusters(img, opts)
+
+		for _, workers := range []int{2, 3, 4, 8} {
+			opts.Workers = workers
+			if got := ExtractClusters(img, opts); !reflect.DeepEqual(got, want) {
+				t.Errorf("stride %d sample %d: %d workers gave %v, 1 worker gave %v",
+					opts.Stride, opts.SampleSize, workers, got, want)
+			}
+		}
+	}
+}
+
+func TestFilterSimilarClustersMergesColors(t *testing.T) {
+	clusters := []Cluster{
+		{Color: Color{255, 0, 0}, Population: 3},
+		{Color: Color{0, 0, 255}, Population: 2},
+		{Color: Color{0, 0, 60}, Population: 1},
+	}
+	got := filterSimilarClusters(clusters, 300, 2)
+
+	// Dark blue is too close to keep, so it is folded into blue, which
+	// becomes the mean of both
+	var blue linearMean
+	blue.add(Color{0, 0, 255}, 2)
+	blue.add(Color{0, 0, 60}, 1)
+	want := []Cluster{
+		{Color: Color{255, 0, 0}, Population: 3},
+		{Color: blue.color(), Population: 3},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,99 @@
+package color
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"time"
+)
+
+// Animation is a sequence of fully composited frames
+type Animation struct {
+	Frames []image.Image
+	// Delays holds how long each frame is shown
+	Delays []time.Duration
+}
+
+// DecodeGIF decodes every frame of a GIF. Frames are composited onto the
+// logical screen following each frame's disposal method, so each one is the
+// full picture shown at that point rather than the changed area alone.
+func DecodeGIF(r io.Reader) (*Animation, error) {
+	g, err := gif.DecodeAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode GIF: %w", err)
+	}
+	if len(g.Image) == 0 {
+		return nil, fmt.Errorf("GIF has no frames")
+	}
+
+	screen := image.Rect(0, 0, g.Config.Width, g.Config.Height)
+	if screen.Empty() {
+		screen = g.Image[0].Bounds()
+	}
+
+	canvas := image.NewRGBA(screen)
+	anim := &Animation{}
+	for i, frame := range g.Image {
+		var previous *image.RGBA
+		if i < len(g.Disposal) && g.Disposal[i] == gif.DisposalPrevious {
+			previous = cloneRGBA(canvas)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		anim.Frames = append(anim.Frames, cloneRGBA(canvas))
+
+		delay := 0
+		if i < len(g.Delay) {
+			delay = g.Delay[i]
+		}
+		// GIF delays are in hundredths of a second
+		anim.Delays = append(anim.Delays, time.Duration(delay)*10*time.Millisecond)
+
+		switch {
+		case previous != nil:
+			canvas = previous
+		case i < len(g.Disposal) && g.Disposal[i] == gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		}
+	}
+
+	return anim, nil
+}
+
+func cloneRGBA(img *image.RGBA) *image.RGBA {
+	clone := image.NewRGBA(img.Bounds())
+	copy(clone.Pix, img.Pix)
+	return clone
+}
+
+// ExtractSequenceClusters extracts one palette for a sequence of frames,
+// aggregating the colors of every frame as if they were one image
+func ExtractSequenceClusters(frames []image.Image, opts ExtractOptions) []Cluster {
+	histograms := make([]*histogram, numWorkers(opts))
+	for i := range histograms {
+		histograms[i] = new(histogram)
+	}
+
+	for _, frame := range frames {
+		sampleImage(frame, opts, func(worker, x, y int, c Color, weight float64) {
+			histograms[worker].add(c, weight)
+		})
+	}
+
+	for _, h := range histograms[1:] {
+		histograms[0].merge(h)
+	}
+	return clusterHistogram(histograms[0].colors(), opts)
+}
+
+// FramePalettes extracts a separate palette from each frame, for showing how
+// the colors change over time
+func FramePalettes(frames []image.Image, opts ExtractOptions) [][]Cluster {
+	palettes := make([][]Cluster, len(frames))
+	for i, frame := range frames {
+		palettes[i] = ExtractClusters(frame, opts)
+	}
+	return palettes
+}
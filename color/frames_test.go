@@ -0,0 +1,94 @@
+package color
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+	"time"
+)
+
+func TestDecodeGIFDisposal(t *testing.T) {
+	var (
+		none  = color.RGBA{}
+		red   = color.RGBA{255, 0, 0, 255}
+		green = color.RGBA{0, 255, 0, 255}
+		blue  = color.RGBA{0, 0, 255, 255}
+		white = color.RGBA{255, 255, 255, 255}
+	)
+	pal := color.Palette{none, red, green, blue, white}
+	frame := func(r image.Rectangle, c color.Color) *image.Paletted {
+		img := image.NewPaletted(r, pal)
+		for y := r.Min.Y; y < r.Max.Y; y++ {
+			for x := r.Min.X; x < r.Max.X; x++ {
+				img.Set(x, y, c)
+			}
+		}
+		return img
+	}
+
+	// A 4 x 4 red background, then a green square restored afterwards, a
+	// blue square cleared afterwards, and a strip that is white on the left
+	// and transparent on the right
+	strip := frame(image.Rect(0, 3, 2, 4), white)
+	strip.Set(1, 3, none)
+	g := &gif.GIF{
+		Image: []*image.Paletted{
+			frame(image.Rect(0, 0, 4, 4), red),
+			frame(image.Rect(0, 0, 2, 2), green),
+			frame(image.Rect(2, 2, 4, 4), blue),
+			strip,
+		},
+		Delay:    []int{10, 20, 30, 40},
+		Disposal: []byte{gif.DisposalNone, gif.DisposalPrevious, gif.DisposalBackground, gif.DisposalNone},
+		Config:   image.Config{ColorModel: pal, Width: 4, Height: 4},
+	}
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, g); err != nil {
+		t.Fatal(err)
+	}
+
+	anim, err := DecodeGIF(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantDelays := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 300 * time.Millisecond, 400 * time.Millisecond}
+	if len(anim.Frames) != 4 || len(anim.Delays) != 4 {
+		t.Fatalf("got %d frames and %d delays, want 4", len(anim.Frames), len(anim.Delays))
+	}
+	for i, d := range anim.Delays {
+		if d != wantDelays[i] {
+			t.Errorf("frame %d: delay %v, want %v", i, d, wantDelays[i])
+		}
+	}
+
+	tests := []struct {
+		frame int
+		at    image.Point
+		want  color.RGBA
+	}{
+		{0, image.Pt(0, 0), red},
+		{0, image.Pt(3, 3), red},
+		{1, image.Pt(0, 0), green},
+		{1, image.Pt(1, 1), green},
+		{1, image.Pt(2, 2), red},
+		// The green square was restored to the red underneath
+		{2, image.Pt(0, 0), red},
+		{2, image.Pt(3, 3), blue},
+		{2, image.Pt(1, 3), red},
+		// The blue square was cleared to transparent
+		{3, image.Pt(3, 3), none},
+		{3, image.Pt(2, 2), none},
+		{3, image.Pt(0, 3), white},
+		// Transparent pixels of a frame leave the canvas showing through
+		{3, image.Pt(1, 3), red},
+		{3, image.Pt(0, 0), red},
+	}
+	for _, tt := range tests {
+		if got := color.RGBAModel.Convert(anim.Frames[tt.frame].At(tt.at.X, tt.at.Y)); got != tt.want {
+			t.Errorf("frame %d at %v: got %v, want %v", tt.frame, tt.at, got, tt.want)
+		}
+	}
+}
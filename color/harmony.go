@@ -0,0 +1,113 @@
+package color
+
+import "math"
+
+// ColorSpace selects the space palette math is done in
+type ColorSpace int
+
+const (
+	// SpaceHSL rotates hue and scales saturation and lightness in HSL
+	SpaceHSL ColorSpace = iota
+	// SpaceOKLCH works in OKLCH, keeping perceived lightness and chroma consistent
+	// across hues and mapping results back into the sRGB gamut
+	SpaceOKLCH
+)
+
+// String returns a human-readable name for the color space
+func (s ColorSpace) String() string {
+	switch s {
+	case SpaceHSL:
+		return "HSL"
+	case SpaceOKLCH:
+		return "OKLCH"
+	default:
+		return "Unknown"
+	}
+}
+
+// PaletteOption configures palette generation
+type PaletteOption func(*paletteConfig)
+
+type paletteConfig struct {
+	space ColorSpace
+}
+
+// WithColorSpace sets the color space harmonies are computed in. The default is SpaceHSL.
+func WithColorSpace(space ColorSpace) PaletteOption {
+	return func(cfg *paletteConfig) {
+		cfg.space = space
+	}
+}
+
+func newPaletteConfig(opts []PaletteOption) paletteConfig {
+	cfg := paletteConfig{space: SpaceHSL}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// tone describes a palette entry relative to the base color
+type tone struct {
+	hue        float64 // Degrees to rotate the base hue by
+	saturation float64 // Multiplier for saturation or chroma
+	lightness  float64 // Multiplier for lightness
+}
+
+// renderTones applies each tone to the base color in the given space
+func renderTones(base Color, tones []tone, space ColorSpace) []Color {
+	colors := make([]Color, len(tones))
+	basePolar := toPolar(base, space)
+	for i, t := range tones {
+		colors[i] = Polar{
+			L: scaleLightness(basePolar.L, t.lightness),
+			C: basePolar.C * t.saturation,
+			H: rotateDegrees(basePolar.H, t.hue),
+		}.color(space)
+	}
+	return colors
+}
+
+// Polar is a color in the cylindrical form of a ColorSpace, as seen by a
+// Transform. Values outside the space's range are brought back into it when
+// the color is converted to sRGB.
+type Polar struct {
+	L float64 // Lightness in [0, 1]
+	C float64 // OKLCH chroma, or HSL saturation in percent
+	H float64 // Hue in degrees
+}
+
+func toPolar(c Color, space ColorSpace) Polar {
+	if space == SpaceOKLCH {
+		lch := c.ToOKLCH()
+		return Polar{L: lch.L, C: lch.C, H: lch.H}
+	}
+	hsl := rgbToHSL(c)
+	return Polar{L: hsl.L / 100, C: hsl.S, H: hsl.H}
+}
+
+// color converts back to sRGB. OKLCH colors are mapped into the gamut by
+// reducing chroma; HSL values are clamped to their range, which always fits.
+func (p Polar) color(space ColorSpace) Color {
+	l := clamp(p.L, 0, 1)
+	c := math.Max(p.C, 0)
+	h := rotateDegrees(p.H, 0)
+	if space == SpaceOKLCH {
+		return OKLCH{L: l, C: c, H: h}.MapToGamut().ToColor()
+	}
+	return hslToRGB(HSL{H: h, S: math.Min(c, 100), L: l * 100})
+}
+
+// scaleLightness scales a lightness in [0, 1] without leaving that range. Factors
+// above 1 shrink the distance to white instead of multiplying past it.
+func scaleLightness(l, factor float64) float64 {
+	if factor > 1 {
+		return clamp(1-(1-l)/factor, 0, 1)
+	}
+	return clamp(l*factor, 0, 1)
+}
+
+// rotateDegrees rotates a hue angle and wraps it into [0, 360)
+func rotateDegrees(h, degrees float64) float64 {
+	return rotateHue(HSL{H: h}, degrees).H
+}
@@ -0,0 +1,397 @@
+package color
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+	"strings"
+	"unicode/utf16"
+)
+
+// ErrUnsupportedProfile is returned for ICC profiles that are not RGB
+// matrix/TRC profiles, such as LUT-based or CMYK profiles
+var ErrUnsupportedProfile = errors.New("unsupported ICC profile")
+
+// ICCProfile is an RGB matrix/TRC ICC profile, the kind used for Display P3,
+// Adobe RGB and most camera and screen profiles
+type ICCProfile struct {
+	Description string
+	// toSRGB maps the profile's linear RGB to linear sRGB
+	toSRGB [3][3]float64
+	curves [3]toneCurve
+}
+
+// toneCurve decodes one channel of the profile to linear light
+type toneCurve func(v float64) float64
+
+// xyzD50ToSRGB converts D50-adapted XYZ, the ICC connection space, to linear
+// sRGB using the Bradford adaptation to D65
+var xyzD50ToSRGB = [3][3]float64{
+	{3.1338561, -1.6168667, -0.4906146},
+	{-0.9787684, 1.9161415, 0.0334540},
+	{0.0719453, -0.2289914, 1.4052427},
+}
+
+// ParseICCProfile reads an ICC profile. Only RGB matrix/TRC profiles can be
+// used for conversion; others return ErrUnsupportedProfile.
+func ParseICCProfile(data []byte) (*ICCProfile, error) {
+	if len(data) < 132 || string(data[36:40]) != "acsp" {
+		return nil, fmt.Errorf("%w: not an ICC profile", ErrUnsupportedProfile)
+	}
+	if space := string(data[16:20]); space != "RGB " {
+		return nil, fmt.Errorf("%w: %q color space", ErrUnsupportedProfile, strings.TrimSpace(space))
+	}
+
+	tags := make(map[string][]byte)
+	count := int(binary.BigEndian.Uint32(data[128:]))
+	for i := 0; i < count; i++ {
+		entry := 132 + i*12
+		if entry+12 > len(data) {
+			break
+		}
+		offset := int(binary.BigEndian.Uint32(data[entry+4:]))
+		size := int(binary.BigEndian.Uint32(data[entry+8:]))
+		if offset < 0 || size < 0 || offset+size > len(data) {
+			continue
+		}
+		tags[string(data[entry:entry+4])] = data[offset : offset+size]
+	}
+
+	p := &ICCProfile{Description: profileDescription(tags["desc"])}
+
+	var colorants [3][3]float64
+	for ch, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz, err := parseXYZTag(tags[sig])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedProfile, sig, err)
+		}
+		for row := range xyz {
+			colorants[row][ch] = xyz[row]
+		}
+	}
+	p.toSRGB = multiplyMatrix(xyzD50ToSRGB, colorants)
+
+	for ch, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		curve, err := parseCurveTag(tags[sig])
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrUnsupportedProfile, sig, err)
+		}
+		p.curves[ch] = curve
+	}
+
+	return p, nil
+}
+
+func s15Fixed16(b []byte) float64 {
+	return float64(int32(binary.BigEndian.Uint32(b))) / 65536
+}
+
+func parseXYZTag(tag []byte) ([3]float64, error) {
+	if len(tag) < 20 || string(tag[:4]) != "XYZ " {
+		return [3]float64{}, fmt.Errorf("missing or malformed XYZ tag")
+	}
+	return [3]float64{s15Fixed16(tag[8:]), s15Fixed16(tag[12:]), s15Fixed16(tag[16:])}, nil
+}
+
+// parseCurveTag reads a curv or para tone curve
+func parseCurveTag(tag []byte) (toneCurve, error) {
+	if len(tag) < 12 {
+		return nil, fmt.Errorf("missing or malformed curve")
+	}
+
+	switch string(tag[:4]) {
+	case "curv":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if len(tag) < 12+2*n {
+			return nil, fmt.Errorf("truncated curve")
+		}
+		switch n {
+		case 0:
+			return func(v float64) float64 { return v }, nil
+		case 1:
+			gamma := float64(binary.BigEndian.Uint16(tag[12:])) / 256
+			return func(v float64) float64 { return math.Pow(v, gamma) }, nil
+		}
+		table := make([]float64, n)
+		for i := range table {
+			table[i] = float64(binary.BigEndian.Uint16(tag[12+2*i:])) / 65535
+		}
+		return func(v float64) float64 {
+			pos := clamp(v, 0, 1) * float64(n-1)
+			i := int(pos)
+			if i >= n-1 {
+				return table[n-1]
+			}
+			frac := pos - float64(i)
+			return table[i]*(1-frac) + table[i+1]*frac
+		}, nil
+
+	case "para":
+		fn := int(binary.BigEndian.Uint16(tag[8:]))
+		counts := []int{1, 3, 4, 5, 7}
+		if fn >= len(counts) || len(tag) < 12+4*counts[fn] {
+			return nil, fmt.Errorf("unsupported parametric curve %d", fn)
+		}
+		// Unused parameters stay zero, which reduces every type to type 4
+		var g, a, b, c, d, e, f float64
+		params := []*float64{&g, &a, &b, &c, &d, &e, &f}
+		for i := 0; i < counts[fn]; i++ {
+			*params[i] = s15Fixed16(tag[12+4*i:])
+		}
+		switch fn {
+		case 0:
+			a, d = 1, math.Inf(-1)
+		case 1, 2:
+			if a == 0 {
+				return nil, fmt.Errorf("parametric curve %d has a zero slope", fn)
+			}
+			// Below -b/a the curve is the constant c, or 0 for type 1
+			d = -b / a
+			e, f = c, c
+			c = 0
+		}
+		return func(v float64) float64 {
+			if v >= d {
+				return math.Pow(math.Max(a*v+b, 0), g) + e
+			}
+			return c*v + f
+		}, nil
+	}
+
+	return nil, fmt.Errorf("unknown curve type %q", tag[:4])
+}
+
+// profileDescription reads the text of a desc or mluc tag
+func profileDescription(tag []byte) string {
+	if len(tag) < 12 {
+		return ""
+	}
+	switch string(tag[:4]) {
+	case "desc":
+		n := int(binary.BigEndian.Uint32(tag[8:]))
+		if 12+n > len(tag) {
+			return ""
+		}
+		return strings.TrimRight(string(tag[12:12+n]), "\x00")
+	case "mluc":
+		// Use the first localized record
+		if len(tag) < 28 || binary.BigEndian.Uint32(tag[8:]) == 0 {
+			return ""
+		}
+		length := int(binary.BigEndian.Uint32(tag[20:]))
+		offset := int(binary.BigEndian.Uint32(tag[24:]))
+		if offset+length > len(tag) {
+			return ""
+		}
+		units := make([]uint16, length/2)
+		for i := range units {
+			units[i] = binary.BigEndian.Uint16(tag[offset+2*i:])
+		}
+		return strings.TrimRight(string(utf16.Decode(units)), "\x00")
+	}
+	return ""
+}
+
+func multiplyMatrix(a, b [3][3]float64) [3][3]float64 {
+	var m [3][3]float64
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			for k := 0; k < 3; k++ {
+				m[i][j] += a[i][k] * b[k][j]
+			}
+		}
+	}
+	return m
+}
+
+// IsSRGB reports whether the profile is close enough to sRGB that converting
+// would change no 8-bit value
+func (p *ICCProfile) IsSRGB() bool {
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			want := 0.0
+			if i == j {
+				want = 1
+			}
+			if math.Abs(p.toSRGB[i][j]-want) > 0.002 {
+				return false
+			}
+		}
+	}
+	for _, curve := range p.curves {
+		for v := 0; v <= 255; v += 5 {
+			if math.Abs(curve(float64(v)/255)-linearTable[v]) > 0.001 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// ConvertedImage is an image converted to sRGB from an embedded ICC profile.
+// It remembers which pixels fell outside the sRGB gamut and were clipped.
+type ConvertedImage struct {
+	*image.NRGBA
+	Profile    *ICCProfile
+	outOfGamut []bool
+}
+
+// OutOfGamut reports whether the pixel at (x, y) had to be clipped
+func (c *ConvertedImage) OutOfGamut(x, y int) bool {
+	if !image.Pt(x, y).In(c.Rect) {
+		return false
+	}
+	return c.outOfGamut[(y-c.Rect.Min.Y)*c.Rect.Dx()+(x-c.Rect.Min.X)]
+}
+
+// OutOfGamutFraction returns the share of pixels that had to be clipped
+func (c *ConvertedImage) OutOfGamutFraction() float64 {
+	if len(c.outOfGamut) == 0 {
+		return 0
+	}
+	n := 0
+	for _, out := range c.outOfGamut {
+		if out {
+			n++
+		}
+	}
+	return float64(n) / float64(len(c.outOfGamut))
+}
+
+// Resized wraps img, a resized copy of the image, with the out-of-gamut mask
+// scaled to match. A pixel of img counts as clipped when any pixel of the
+// original that it covers was.
+func (c *ConvertedImage) Resized(img *image.NRGBA) *ConvertedImage {
+	src, dst := c.Rect, img.Rect
+	out := &ConvertedImage{
+		NRGBA:      img,
+		Profile:    c.Profile,
+		outOfGamut: make([]bool, dst.Dx()*dst.Dy()),
+	}
+	if src.Empty() {
+		return out
+	}
+
+	for y := 0; y < dst.Dy(); y++ {
+		y0, y1 := coveredRange(y, dst.Dy(), src.Dy())
+		for x := 0; x < dst.Dx(); x++ {
+			x0, x1 := coveredRange(x, dst.Dx(), src.Dx())
+			clipped := false
+			for sy := y0; sy < y1 && !clipped; sy++ {
+				for sx := x0; sx < x1 && !clipped; sx++ {
+					clipped = c.outOfGamut[sy*src.Dx()+sx]
+				}
+			}
+			out.outOfGamut[y*dst.Dx()+x] = clipped
+		}
+	}
+	return out
+}
+
+// coveredRange returns the span of the n source pixels that destination pixel
+// i of m covers, which is never empty
+func coveredRange(i, m, n int) (int, int) {
+	return i * n / m, ceilDiv((i+1)*n, m)
+}
+
+// gamutReporter is implemented by images that know which pixels were clipped
+// to fit sRGB
+type gamutReporter interface {
+	OutOfGamut(x, y int) bool
+}
+
+const (
+	// encodeSteps is the resolution of the linear to sRGB lookup table
+	encodeSteps = 1 << 14
+	// iccGamutTolerance absorbs the rounding of the profile's fixed-point
+	// values when deciding whether a pixel left the sRGB gamut
+	iccGamutTolerance = 1e-3
+)
+
+// ConvertToSRGB converts an image encoded in the profile's color space to sRGB
+func (p *ICCProfile) ConvertToSRGB(img image.Image) *ConvertedImage {
+	bounds := img.Bounds()
+	src := image.NewNRGBA(bounds)
+	draw.Draw(src, bounds, img, bounds.Min, draw.Src)
+
+	var decode [3][256]float64
+	for ch, curve := range p.curves {
+		for v := range decode[ch] {
+			decode[ch][v] = curve(float64(v) / 255)
+		}
+	}
+	var encode [encodeSteps + 1]uint8
+	for i := range encode {
+		encode[i] = encodeChannel(float64(i) / encodeSteps)
+	}
+
+	out := &ConvertedImage{
+		NRGBA:      src,
+		Profile:    p,
+		outOfGamut: make([]bool, bounds.Dx()*bounds.Dy()),
+	}
+	m := p.toSRGB
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			i := src.PixOffset(x, y)
+			px := src.Pix[i : i+3 : i+3]
+			r, g, b := decode[0][px[0]], decode[1][px[1]], decode[2][px[2]]
+
+			clipped := false
+			for ch := 0; ch < 3; ch++ {
+				v := m[ch][0]*r + m[ch][1]*g + m[ch][2]*b
+				if v < -iccGamutTolerance || v > 1+iccGamutTolerance {
+					clipped = true
+				}
+				px[ch] = encode[int(clamp(v, 0, 1)*encodeSteps+0.5)]
+			}
+			out.outOfGamut[(y-bounds.Min.Y)*bounds.Dx()+(x-bounds.Min.X)] = clipped
+		}
+	}
+	return out
+}
+
+// findICCProfile returns the embedded ICC profile of an encoded image, or nil
+func findICCProfile(data []byte, format string) []byte {
+	switch format {
+	case "jpeg":
+		// Large profiles are split over several APP2 segments, each starting
+		// with its sequence number and the segment count
+		var profile []byte
+		chunks := jpegSegments(data, 0xE2, []byte("ICC_PROFILE\x00"))
+		for seq := 1; seq <= len(chunks); seq++ {
+			for _, chunk := range chunks {
+				if len(chunk) > 2 && int(chunk[0]) == seq {
+					profile = append(profile, chunk[2:]...)
+				}
+			}
+		}
+		return profile
+	case "png":
+		// iCCP holds a profile name, a compression method and zlib data
+		chunk := pngChunk(data, "iCCP")
+		name := bytes.IndexByte(chunk, 0)
+		if name < 0 || name+2 > len(chunk) {
+			return nil
+		}
+		r, err := zlib.NewReader(bytes.NewReader(chunk[name+2:]))
+		if err != nil {
+			return nil
+		}
+		defer r.Close()
+		profile, err := io.ReadAll(r)
+		if err != nil {
+			return nil
+		}
+		return profile
+	case "webp":
+		return riffChunk(data, "ICCP")
+	}
+	return nil
+}
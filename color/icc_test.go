@@ -0,0 +1,388 @@
+package color
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"math"
+	"testing"
+)
+
+// D50-adapted colorants, one XYZ column per channel, as written in the
+// rXYZ, gXYZ and bXYZ tags
+var (
+	srgbColorants = [3][3]float64{
+		{0.436066, 0.222488, 0.013916},
+		{0.385147, 0.716873, 0.097076},
+		{0.143066, 0.060608, 0.714096},
+	}
+	displayP3Colorants = [3][3]float64{
+		{0.515102, 0.241182, -0.001050},
+		{0.291965, 0.692236, 0.041882},
+		{0.157153, 0.066582, 0.784168},
+	}
+)
+
+func s15(v float64) []byte {
+	return binary.BigEndian.AppendUint32(nil, uint32(int32(math.Round(v*65536))))
+}
+
+// paraCurve builds a parametric curve tag of the given type
+func paraCurve(fn uint16, params ...float64) []byte {
+	tag := append([]byte("para\x00\x00\x00\x00"), byte(fn>>8), byte(fn), 0, 0)
+	for _, v := range params {
+		tag = append(tag, s15(v)...)
+	}
+	return tag
+}
+
+// srgbCurve is the sRGB transfer function as a type 3 parametric curve
+var srgbCurve = paraCurve(3, 2.4, 1/1.055, 0.055/1.055, 1/12.92, 0.04045)
+
+// buildICCProfile builds a minimal RGB matrix/TRC profile using the same
+// curve for every channel
+func buildICCProfile(desc string, colorants [3][3]float64, curve []byte) []byte {
+	descTag := append([]byte("desc\x00\x00\x00\x00"), binary.BigEndian.AppendUint32(nil, uint32(len(desc)+1))...)
+	descTag = append(append(descTag, desc...), 0)
+
+	tags := []struct {
+		sig  string
+		data []byte
+	}{{"desc", descTag}}
+	for ch, sig := range []string{"rXYZ", "gXYZ", "bXYZ"} {
+		xyz := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range colorants[ch] {
+			xyz = append(xyz, s15(v)...)
+		}
+		tags = append(tags, struct {
+			sig  string
+			data []byte
+		}{sig, xyz})
+	}
+	for _, sig := range []string{"rTRC", "gTRC", "bTRC"} {
+		tags = append(tags, struct {
+			sig  string
+			data []byte
+		}{sig, curve})
+	}
+
+	header := make([]byte, 128)
+	copy(header[12:], "mntr")
+	copy(header[16:], "RGB ")
+	copy(header[20:], "XYZ ")
+	copy(header[36:], "acsp")
+
+	table := binary.BigEndian.AppendUint32(nil, uint32(len(tags)))
+	var data []byte
+	offset := 128 + 4 + 12*len(tags)
+	for _, tag := range tags {
+		table = append(table, tag.sig...)
+		table = binary.BigEndian.AppendUint32(table, uint32(offset+len(data)))
+		table = binary.BigEndian.AppendUint32(table, uint32(len(tag.data)))
+		data = append(data, tag.data...)
+		for len(data)%4 != 0 {
+			data = append(data, 0)
+		}
+	}
+
+	profile := append(append(header, table...), data...)
+	binary.BigEndian.PutUint32(profile, uint32(len(profile)))
+	return profile
+}
+
+// p3ToSRGB converts an 8-bit Display P3 color to unclipped linear sRGB with
+// the standard matrix
+func p3ToSRGB(c Color) [3]float64 {
+	r, g, b := linearTable[c.R], linearTable[c.G], linearTable[c.B]
+	return [3]float64{
+		1.2249401*r - 0.2249404*g,
+		-0.0420569*r + 1.0420571*g,
+		-0.0196376*r - 0.0786361*g + 1.0982735*b,
+	}
+}
+
+// p3TestImage returns an image of Display P3 colors, some outside sRGB
+func p3TestImage() (*image.NRGBA, []Color) {
+	colors := []Color{
+		{255, 0, 0},
+		{0, 255, 0},
+		{128, 128, 128},
+		{255, 255, 255},
+		{200, 100, 50},
+		{60, 120, 180},
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, len(colors), 1))
+	for i, c := range colors {
+		copy(img.Pix[4*i:], []byte{c.R, c.G, c.B, 255})
+	}
+	return img, colors
+}
+
+// checkP3Conversion checks that img holds the colors of p3TestImage
+// converted to sRGB, with the out-of-sRGB ones flagged
+func checkP3Conversion(t *testing.T, img image.Image) {
+	t.Helper()
+	converted, ok := img.(*ConvertedImage)
+	if !ok {
+		t.Fatalf("got %T, want *ConvertedImage", img)
+	}
+
+	_, colors := p3TestImage()
+	for i, c := range colors {
+		lin := p3ToSRGB(c)
+		outside := false
+		var enc [3]uint8
+		for ch, v := range lin {
+			outside = outside || v < -0.001 || v > 1.001
+			enc[ch] = encodeChannel(clamp(v, 0, 1))
+		}
+		want := Color{enc[0], enc[1], enc[2]}
+
+		p := converted.NRGBAAt(i, 0)
+		got := Color{p.R, p.G, p.B}
+		if colorDistance(got, want) > 2 {
+			t.Errorf("P3 %s: got %s, want %s", c.Hex(), got.Hex(), want.Hex())
+		}
+		if converted.OutOfGamut(i, 0) != outside {
+			t.Errorf("P3 %s: out of gamut %v, want %v", c.Hex(), converted.OutOfGamut(i, 0), outside)
+		}
+	}
+	if !converted.OutOfGamut(0, 0) {
+		t.Error("pure P3 red not flagged as out of gamut")
+	}
+}
+
+func TestConvertDisplayP3(t *testing.T) {
+	profile, err := ParseICCProfile(buildICCProfile("Display P3", displayP3Colorants, srgbCurve))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Description != "Display P3" {
+		t.Errorf("description %q, want %q", profile.Description, "Display P3")
+	}
+	if profile.IsSRGB() {
+		t.Error("Display P3 reported as sRGB")
+	}
+
+	img, _ := p3TestImage()
+	checkP3Conversion(t, profile.ConvertToSRGB(img))
+}
+
+func TestIsSRGB(t *testing.T) {
+	tests := []struct {
+		name      string
+		colorants [3][3]float64
+		curve     []byte
+		want      bool
+	}{
+		{"sRGB", srgbColorants, srgbCurve, true},
+		{"Display P3", displayP3Colorants, srgbCurve, false},
+		{"sRGB primaries with gamma 1.8", srgbColorants, paraCurve(0, 1.8), false},
+	}
+	for _, tt := range tests {
+		profile, err := ParseICCProfile(buildICCProfile(tt.name, tt.colorants, tt.curve))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := profile.IsSRGB(); got != tt.want {
+			t.Errorf("%s: IsSRGB() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestParseCurveTag(t *testing.T) {
+	// Parameters exactly representable in s15Fixed16
+	const g, a, b, c, d, e, f = 2.5, 0.75, 0.25, 0.5, 0.25, 0.125, 0.0625
+	pow := func(v float64) float64 { return math.Pow(v, g) }
+
+	tests := []struct {
+		name string
+		tag  []byte
+		want func(x float64) float64
+	}{
+		{"type 0", paraCurve(0, g), func(x float64) float64 { return pow(x) }},
+		{"type 1", paraCurve(1, g, a, -b), func(x float64) float64 {
+			if x >= b/a {
+				return pow(a*x - b)
+			}
+			return 0
+		}},
+		{"type 2", paraCurve(2, g, a, -b, c), func(x float64) float64 {
+			if x >= b/a {
+				return pow(a*x-b) + c
+			}
+			return c
+		}},
+		{"type 3", paraCurve(3, g, a, b, c, d), func(x float64) float64 {
+			if x >= d {
+				return pow(a*x + b)
+			}
+			return c * x
+		}},
+		{"type 4", paraCurve(4, g, a, b, c, d, e, f), func(x float64) float64 {
+			if x >= d {
+				return pow(a*x+b) + e
+			}
+			return c*x + f
+		}},
+		{"identity", []byte("curv\x00\x00\x00\x00\x00\x00\x00\x00"), func(x float64) float64 { return x }},
+		{"gamma", []byte("curv\x00\x00\x00\x00\x00\x00\x00\x01\x02\x80"), func(x float64) float64 { return pow(x) }},
+		{"table", []byte("curv\x00\x00\x00\x00\x00\x00\x00\x03\x00\x00\x40\x00\xff\xff"), func(x float64) float64 {
+			if x < 0.5 {
+				return x * 0x4000 / 0xffff * 2
+			}
+			return 0x4000/float64(0xffff) + (x-0.5)*2*(1-0x4000/float64(0xffff))
+		}},
+	}
+	for _, tt := range tests {
+		curve, err := parseCurveTag(tt.tag)
+		if err != nil {
+			t.Errorf("%s: %v", tt.name, err)
+			continue
+		}
+		for _, x := range []float64{0, 0.1, 0.3, 0.5, 0.8, 1} {
+			if got, want := curve(x), tt.want(x); math.Abs(got-want) > 1e-9 {
+				t.Errorf("%s at %v: got %v, want %v", tt.name, x, got, want)
+			}
+		}
+	}
+}
+
+func TestParseCurveTagErrors(t *testing.T) {
+	tests := map[string][]byte{
+		"type 1 with a = 0": paraCurve(1, 2.2, 0, 0.5),
+		"type 2 with a = 0": paraCurve(2, 2.2, 0, 0.5, 0.1),
+		"unknown type":      paraCurve(5, 2.2, 1, 0, 0, 0, 0, 0),
+		"missing params":    paraCurve(3, 2.4, 1),
+		"truncated table":   []byte("curv\x00\x00\x00\x00\x00\x00\x00\x04\x00\x00"),
+		"unknown tag":       []byte("mAB \x00\x00\x00\x00\x00\x00\x00\x00"),
+		"too short":         []byte("para"),
+	}
+	for name, tag := range tests {
+		if _, err := parseCurveTag(tag); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+
+	profile := buildICCProfile("bad", displayP3Colorants, paraCurve(1, 2.2, 0, 0.5))
+	if _, err := ParseICCProfile(profile); !errors.Is(err, ErrUnsupportedProfile) {
+		t.Errorf("profile with a zero-slope curve: got %v, want ErrUnsupportedProfile", err)
+	}
+}
+
+func TestJPEGProfileSegments(t *testing.T) {
+	profile := buildICCProfile("Display P3", displayP3Colorants, srgbCurve)
+	img, _ := p3TestImage()
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, &jpeg.Options{Quality: 100}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Split the profile over three APP2 segments, stored out of order
+	third := len(profile) / 3
+	chunks := [][]byte{profile[:third], profile[third : 2*third], profile[2*third:]}
+	data := []byte{0xFF, 0xD8}
+	for _, seq := range []int{2, 3, 1} {
+		payload := append([]byte("ICC_PROFILE\x00"), byte(seq), 3)
+		payload = append(payload, chunks[seq-1]...)
+		data = append(data, 0xFF, 0xE2)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(payload)+2))
+		data = append(data, payload...)
+	}
+	data = append(data, encoded.Bytes()[2:]...)
+
+	if got := findICCProfile(data, "jpeg"); !bytes.Equal(got, profile) {
+		t.Fatalf("reassembled profile of %d bytes, want %d", len(got), len(profile))
+	}
+
+	// JPEG compression shifts colors slightly, so only check the flag
+	decoded, _, err := DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if converted, ok := decoded.(*ConvertedImage); !ok || !converted.OutOfGamut(0, 0) {
+		t.Errorf("got %T without pure red flagged, want a converted image", decoded)
+	}
+}
+
+func TestPNGProfileChunk(t *testing.T) {
+	profile := buildICCProfile("Display P3", displayP3Colorants, srgbCurve)
+	img, _ := p3TestImage()
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, img); err != nil {
+		t.Fatal(err)
+	}
+
+	var compressed bytes.Buffer
+	zw := zlib.NewWriter(&compressed)
+	zw.Write(profile)
+	zw.Close()
+	chunk := append([]byte("iCCPDisplay P3\x00\x00"), compressed.Bytes()...)
+
+	// iCCP goes right after the 8-byte signature and the 25-byte IHDR chunk
+	raw := encoded.Bytes()
+	data := append([]byte(nil), raw[:33]...)
+	data = binary.BigEndian.AppendUint32(data, uint32(len(chunk)-4))
+	data = append(data, chunk...)
+	data = binary.BigEndian.AppendUint32(data, crc32.ChecksumIEEE(chunk))
+	data = append(data, raw[33:]...)
+
+	if got := findICCProfile(data, "png"); !bytes.Equal(got, profile) {
+		t.Fatalf("extracted profile of %d bytes, want %d", len(got), len(profile))
+	}
+
+	decoded, format, err := DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != "png" {
+		t.Errorf("format %q, want png", format)
+	}
+	checkP3Conversion(t, decoded)
+}
+
+func TestConvertedImageResized(t *testing.T) {
+	// A 10 x 4 image with a single clipped pixel at (7, 1)
+	src := &ConvertedImage{
+		NRGBA:      image.NewNRGBA(image.Rect(0, 0, 10, 4)),
+		outOfGamut: make([]bool, 40),
+	}
+	src.outOfGamut[1*10+7] = true
+
+	tests := []struct {
+		name    string
+		rect    image.Rectangle
+		clipped []image.Point
+	}{
+		{"half size", image.Rect(0, 0, 5, 2), []image.Point{{3, 0}}},
+		{"uneven", image.Rect(0, 0, 3, 3), []image.Point{{2, 0}, {2, 1}}},
+		{"single pixel", image.Rect(0, 0, 1, 1), []image.Point{{0, 0}}},
+		{"same size", image.Rect(0, 0, 10, 4), []image.Point{{7, 1}}},
+		{"upscaled", image.Rect(0, 0, 20, 8), []image.Point{{14, 2}, {15, 2}, {14, 3}, {15, 3}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := src.Resized(image.NewNRGBA(tt.rect))
+			if got.Bounds() != tt.rect {
+				t.Fatalf("bounds %v, want %v", got.Bounds(), tt.rect)
+			}
+
+			want := make(map[image.Point]bool)
+			for _, p := range tt.clipped {
+				want[p] = true
+			}
+			for y := tt.rect.Min.Y; y < tt.rect.Max.Y; y++ {
+				for x := tt.rect.Min.X; x < tt.rect.Max.X; x++ {
+					if got.OutOfGamut(x, y) != want[image.Pt(x, y)] {
+						t.Errorf("pixel (%d, %d): clipped %v, want %v", x, y, got.OutOfGamut(x, y), want[image.Pt(x, y)])
+					}
+				}
+			}
+		})
+	}
+}
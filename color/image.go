@@ -10,6 +10,7 @@ import (
 
 	"github.com/fogleman/gg"
 	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
 )
 
 //go:embed fonts/WorkSans-Regular.ttf fonts/WorkSans-Bold.ttf
@@ -28,6 +29,15 @@ type PaletteImage struct {
 	Names     []string
 	HexCodes  []string
 	InputPath string // Optional input image path
+	// Optional relative bar widths, such as each color's share of the input image
+	Weights []float64
+	// Optional opacity of each color; translucent bars are drawn over a checkerboard
+	Alphas []uint8
+	// Optional caption drawn above each hex code, such as the color's role
+	Labels []string
+	// Optional position of each color in the input image. Numbered markers are
+	// drawn there and on the matching bars.
+	Markers []image.Point
 
 	// Optional text options
 	ShowHexCodes bool
@@ -85,6 +95,9 @@ func GeneratePaletteImage(cfg PaletteImage) (image.Image, error) {
 	boldFace := truetype.NewFace(boldFont, &truetype.Options{
 		Size: float64(fontSize),
 	})
+	labelFace := truetype.NewFace(regularFont, &truetype.Options{
+		Size: float64(fontSize) * 0.6,
+	})
 
 	// Calculate color bar dimensions
 	numColors := len(cfg.Colors)
@@ -95,9 +108,10 @@ func GeneratePaletteImage(cfg PaletteImage) (image.Image, error) {
 	var barHeight float64
 	startY := 0.0
 
+	var photo *photoTransform
 	if cfg.InputPath != "" {
 		// If we have an input image, draw it first
-		if err := drawInputImage(dc, cfg.InputPath); err != nil {
+		if photo, err = drawInputImage(dc, cfg.InputPath); err != nil {
 			return nil, err
 		}
 		// Color bars take up bottom 1/4 of the image
@@ -108,19 +122,27 @@ func GeneratePaletteImage(cfg PaletteImage) (image.Image, error) {
 		barHeight = float64(imageSize)
 	}
 
-	barWidth := float64(imageSize) / float64(numColors)
+	barWidths := calculateBarWidths(numColors, cfg.Weights)
 
 	// Draw color bars and text
+	x := 0.0
 	for i, color := range cfg.Colors {
-		x := float64(i) * barWidth
+		barWidth := barWidths[i]
 
 		// Draw color bar
-		dc.SetColor(color.ToRGBA())
+		fill := color.WithAlpha(255)
+		if i < len(cfg.Alphas) {
+			fill.A = cfg.Alphas[i]
+		}
+		if !fill.Opaque() {
+			drawCheckerboard(dc, x, startY, barWidth, barHeight)
+		}
+		dc.SetColor(fill)
 		dc.DrawRectangle(x, startY, barWidth, barHeight)
 		dc.Fill()
 
 		// Draw text
-		dc.SetColor(getContrastColor(color))
+		dc.SetColor(getContrastColor(fill.Over(checkerMean)))
 
 		// Calculate text positions
 		hexY := startY + (barHeight * 0.33)        // Position hex code 1/3 down the bar
@@ -133,6 +155,14 @@ func GeneratePaletteImage(cfg PaletteImage) (image.Image, error) {
 			hexText = hexText[1:]
 		}
 
+		if i < len(cfg.Labels) && cfg.Labels[i] != "" {
+			dc.SetFontFace(labelFace)
+			label := strings.ToUpper(cfg.Labels[i])
+			textWidth, _ := dc.MeasureString(label)
+			textX := x + (barWidth-textWidth)/2
+			dc.DrawString(label, textX, hexY-float64(fontSize)*1.1)
+		}
+
 		if cfg.ShowHexCodes {
 			// Switch to bold font for hex code
 			dc.SetFontFace(boldFace)
@@ -159,16 +189,130 @@ func GeneratePaletteImage(cfg PaletteImage) (image.Image, error) {
 				}
 			}
 		}
+
+		if photo != nil && i < len(cfg.Markers) {
+			drawMarker(dc, boldFace, i+1, x+barWidth/2, startY+barHeight-float64(fontSize)*1.2, fill.Over(checkerMean))
+		}
+
+		x += barWidth
+	}
+
+	// Markers go on the photo last so bars never cover them
+	if photo != nil {
+		for i, p := range cfg.Markers {
+			if i >= numColors {
+				break
+			}
+			if mx, my, ok := photo.apply(p); ok {
+				drawMarker(dc, boldFace, i+1, mx, my, cfg.Colors[i])
+			}
+		}
 	}
 
 	return dc.Image(), nil
 }
 
-// drawInputImage draws the input image at the top of the context
-func drawInputImage(dc *gg.Context, inputPath string) error {
-	img, err := gg.LoadImage(inputPath)
+// drawMarker draws a numbered circle filled with c, outlined for contrast
+func drawMarker(dc *gg.Context, face font.Face, number int, x, y float64, c Color) {
+	radius := float64(face.Metrics().Height.Ceil()) * 0.6
+
+	dc.DrawCircle(x, y, radius)
+	dc.SetColor(c.ToRGBA())
+	dc.FillPreserve()
+	dc.SetColor(getContrastColor(c))
+	dc.SetLineWidth(3)
+	dc.Stroke()
+
+	dc.SetFontFace(face)
+	dc.DrawStringAnchored(fmt.Sprint(number), x, y, 0.5, 0.35)
+}
+
+// minBarShare is the smallest fraction of the width a weighted bar may take,
+// so that small colors stay visible and legible
+const minBarShare = 0.08
+
+// calculateBarWidths splits the image width between the bars, proportionally
+// to weights when one is given for every color and equally otherwise
+func calculateBarWidths(numColors int, weights []float64) []float64 {
+	widths := make([]float64, numColors)
+
+	total := 0.0
+	if len(weights) == numColors {
+		for _, w := range weights {
+			total += math.Max(w, 0)
+		}
+	}
+	if total == 0 {
+		for i := range widths {
+			widths[i] = float64(imageSize) / float64(numColors)
+		}
+		return widths
+	}
+
+	// Give every bar the minimum share and split the rest by weight
+	minShare := math.Min(minBarShare, 1/float64(numColors))
+	remaining := 1 - minShare*float64(numColors)
+	for i, w := range weights {
+		widths[i] = float64(imageSize) * (minShare + remaining*math.Max(w, 0)/total)
+	}
+	return widths
+}
+
+// checkerSize is the side of a transparency checkerboard square
+const checkerSize = 24
+
+var (
+	checkerLight = Color{R: 0xFF, G: 0xFF, B: 0xFF}
+	checkerDark  = Color{R: 0xCC, G: 0xCC, B: 0xCC}
+	// checkerMean is the average checkerboard color, used to pick text contrast
+	checkerMean = Color{R: 0xE6, G: 0xE6, B: 0xE6}
+)
+
+// drawCheckerboard fills a rectangle with the usual transparency pattern.
+// Squares are aligned to the image so neighbouring bars line up.
+func drawCheckerboard(dc *gg.Context, x, y, w, h float64) {
+	dc.SetColor(checkerLight.ToRGBA())
+	dc.DrawRectangle(x, y, w, h)
+	dc.Fill()
+
+	dc.Push()
+	dc.DrawRectangle(x, y, w, h)
+	dc.Clip()
+	dc.SetColor(checkerDark.ToRGBA())
+	for row := int(y / checkerSize); float64(row*checkerSize) < y+h; row++ {
+		for col := int(x / checkerSize); float64(col*checkerSize) < x+w; col++ {
+			if (row+col)%2 == 1 {
+				dc.DrawRectangle(float64(col*checkerSize), float64(row*checkerSize), checkerSize, checkerSize)
+			}
+		}
+	}
+	dc.Fill()
+	dc.ResetClip()
+	dc.Pop()
+}
+
+// photoTransform maps input image coordinates to where drawInputImage drew them
+type photoTransform struct {
+	cropX, cropY     float64
+	scale            float64
+	offsetX, offsetY float64
+	visible          image.Rectangle
+}
+
+// apply returns the output position of an input pixel and whether it is
+// visible after cropping
+func (t photoTransform) apply(p image.Point) (float64, float64, bool) {
+	x := (float64(p.X)-t.cropX)*t.scale + t.offsetX
+	y := (float64(p.Y)-t.cropY)*t.scale + t.offsetY
+	return x, y, image.Pt(int(x), int(y)).In(t.visible)
+}
+
+// drawInputImage draws the input image at the top of the context and returns
+// how input coordinates map onto it
+func drawInputImage(dc *gg.Context, inputPath string) (*photoTransform, error) {
+	img, err := LoadImage(inputPath)
 	if err != nil {
-		return fmt.Errorf("failed to load input image: %w", err)
+		return nil, fmt.Errorf("failed to load input image: %w", err)
 	}
 
 	// Calculate dimensions for 4:3 crop
@@ -223,7 +367,16 @@ func drawInputImage(dc *gg.Context, inputPath string) error {
 
 	// Draw the final image onto the main context
 	dc.DrawImage(scaledDC.Image(), int(x), int(y))
-	return nil
+
+	return &photoTransform{
+		cropX:   cropX,
+		cropY:   cropY,
+		scale:   scale,
+		offsetX: float64(int(x)),
+		offsetY: float64(int(y)),
+		visible: image.Rect(int(x), int(y), int(x+finalWidth), int(y+finalHeight)).
+			Intersect(image.Rect(0, 0, int(availableWidth), int(availableHeight))),
+	}, nil
 }
 
 // getContrastColor returns white or black depending on which provides better contrast
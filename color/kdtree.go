@@ -0,0 +1,133 @@
+package color
+
+import (
+	"math"
+	"sort"
+)
+
+// kdTree is a static 3-d tree over color coordinates used for nearest-name lookups
+type kdTree struct {
+	nodes []kdNode
+	root  int
+}
+
+type kdNode struct {
+	point       [3]float64
+	index       int // Index of the color in the matcher
+	axis        int
+	left, right int // -1 when there is no child
+}
+
+// kdNeighbor is a tree entry along with its distance from the query
+type kdNeighbor struct {
+	index int
+	dist  float64
+}
+
+// newKDTree builds a balanced tree; points[i] is stored with index i
+func newKDTree(points [][3]float64) *kdTree {
+	order := make([]int, len(points))
+	for i := range order {
+		order[i] = i
+	}
+
+	t := &kdTree{nodes: make([]kdNode, 0, len(points))}
+	t.root = t.build(points, order, 0)
+	return t
+}
+
+func (t *kdTree) build(points [][3]float64, order []int, depth int) int {
+	if len(order) == 0 {
+		return -1
+	}
+
+	axis := depth % 3
+	sort.Slice(order, func(i, j int) bool {
+		return points[order[i]][axis] < points[order[j]][axis]
+	})
+
+	median := len(order) / 2
+	idx := len(t.nodes)
+	t.nodes = append(t.nodes, kdNode{
+		point: points[order[median]],
+		index: order[median],
+		axis:  axis,
+	})
+
+	// Children are built after the parent is appended, so set them afterwards
+	left := t.build(points, order[:median], depth+1)
+	right := t.build(points, order[median+1:], depth+1)
+	t.nodes[idx].left = left
+	t.nodes[idx].right = right
+
+	return idx
+}
+
+// nearest returns the k entries closest to target in Euclidean distance,
+// ordered from closest to farthest
+func (t *kdTree) nearest(target [3]float64, k int) []kdNeighbor {
+	return t.nearestBy(target, k, [3]float64{1, 1, 1}, func(point [3]float64, _ int) float64 {
+		return math.Sqrt(distanceSq(point, target))
+	})
+}
+
+// nearestBy returns the k entries closest to target by dist, ordered from
+// closest to farthest. scale bounds dist from below: an entry that differs
+// from target by d along an axis must be at least d*scale[axis] away. That
+// lets the search skip subtrees for non-Euclidean metrics and stay exact.
+func (t *kdTree) nearestBy(target [3]float64, k int, scale [3]float64, dist func(point [3]float64, index int) float64) []kdNeighbor {
+	if k <= 0 || t.root < 0 {
+		return nil
+	}
+
+	best := make([]kdNeighbor, 0, k)
+	t.search(t.root, target, k, scale, dist, &best)
+	return best
+}
+
+func (t *kdTree) search(idx int, target [3]float64, k int, scale [3]float64, dist func([3]float64, int) float64, best *[]kdNeighbor) {
+	if idx < 0 {
+		return
+	}
+
+	node := &t.nodes[idx]
+	insertNeighbor(best, k, kdNeighbor{index: node.index, dist: dist(node.point, node.index)})
+
+	diff := target[node.axis] - node.point[node.axis]
+	near, far := node.left, node.right
+	if diff > 0 {
+		near, far = far, near
+	}
+
+	t.search(near, target, k, scale, dist, best)
+
+	// Only visit the far side if the splitting plane is closer than the worst kept match
+	if len(*best) < k || math.Abs(diff)*scale[node.axis] < (*best)[len(*best)-1].dist {
+		t.search(far, target, k, scale, dist, best)
+	}
+}
+
+// insertNeighbor keeps best sorted by distance and at most k long
+func insertNeighbor(best *[]kdNeighbor, k int, n kdNeighbor) {
+	list := *best
+	if len(list) == k && n.dist >= list[len(list)-1].dist {
+		return
+	}
+
+	pos := sort.Search(len(list), func(i int) bool {
+		return list[i].dist > n.dist
+	})
+	if len(list) < k {
+		list = append(list, kdNeighbor{})
+	}
+	copy(list[pos+1:], list[pos:])
+	list[pos] = n
+	*best = list
+}
+
+func distanceSq(a, b [3]float64) float64 {
+	d0 := a[0] - b[0]
+	d1 := a[1] - b[1]
+	d2 := a[2] - b[2]
+	return d0*d0 + d1*d1 + d2*d2
+}
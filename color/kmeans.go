@@ -0,0 +1,140 @@
+package color
+
+import (
+	"math/rand"
+)
+
+// KMeans clusters colors in CIELAB with k-means++ seeding followed by Lloyd
+// refinement, so clusters follow perceived rather than RGB distance
+type KMeans struct {
+	// Iterations caps the number of refinement passes; 0 means 20
+	Iterations int
+	// Seed makes the k-means++ seeding reproducible
+	Seed int64
+}
+
+// Extract implements Extractor
+func (km KMeans) Extract(colors []WeightedColor, k int) []Cluster {
+	if len(colors) == 0 || k < 1 {
+		return nil
+	}
+
+	iterations := km.Iterations
+	if iterations <= 0 {
+		iterations = 20
+	}
+
+	points := make([]Lab, len(colors))
+	for i, c := range colors {
+		points[i] = c.ToLab()
+	}
+
+	rng := rand.New(rand.NewSource(km.Seed))
+	centers := kMeansPlusPlus(points, colors, k, rng)
+
+	assignments := make([]int, len(points))
+	weights := make([]float64, len(centers))
+	for iter := 0; iter < iterations; iter++ {
+		changed := iter == 0
+		for i, p := range points {
+			nearest := nearestCenter(p, centers)
+			if nearest != assignments[i] {
+				assignments[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		// Move each center to the weighted mean of its points
+		sums := make([]Lab, len(centers))
+		for i := range weights {
+			weights[i] = 0
+		}
+		for i, p := range points {
+			c, w := assignments[i], colors[i].Weight
+			sums[c].L += p.L * w
+			sums[c].A += p.A * w
+			sums[c].B += p.B * w
+			weights[c] += w
+		}
+		for c := range centers {
+			if weights[c] > 0 {
+				centers[c] = Lab{
+					L: sums[c].L / weights[c],
+					A: sums[c].A / weights[c],
+					B: sums[c].B / weights[c],
+				}
+			}
+		}
+	}
+
+	clusters := make([]Cluster, 0, len(centers))
+	for c, center := range centers {
+		if weights[c] > 0 {
+			clusters = append(clusters, Cluster{Color: center.ToColor(), Population: weights[c]})
+		}
+	}
+	return clusters
+}
+
+// kMeansPlusPlus picks up to k initial centers, each new one chosen with
+// probability proportional to its weight times its squared distance from the
+// centers picked so far
+func kMeansPlusPlus(points []Lab, colors []WeightedColor, k int, rng *rand.Rand) []Lab {
+	distances := make([]float64, len(points))
+	for i := range distances {
+		distances[i] = 1
+	}
+
+	var centers []Lab
+	for len(centers) < k {
+		total := 0.0
+		for i, d := range distances {
+			total += d * colors[i].Weight
+		}
+		// Every remaining point already coincides with a center
+		if total <= 0 {
+			break
+		}
+
+		target := rng.Float64() * total
+		chosen := len(points) - 1
+		for i, d := range distances {
+			target -= d * colors[i].Weight
+			if target <= 0 {
+				chosen = i
+				break
+			}
+		}
+		center := points[chosen]
+		centers = append(centers, center)
+
+		for i, p := range points {
+			if d := labDistanceSq(p, center); len(centers) == 1 || d < distances[i] {
+				distances[i] = d
+			}
+		}
+	}
+	return centers
+}
+
+func nearestCenter(p Lab, centers []Lab) int {
+	nearest := 0
+	best := labDistanceSq(p, centers[0])
+	for i := 1; i < len(centers); i++ {
+		if d := labDistanceSq(p, centers[i]); d < best {
+			best = d
+			nearest = i
+		}
+	}
+	return nearest
+}
+
+func labDistanceSq(a, b Lab) float64 {
+	dL := a.L - b.L
+	dA := a.A - b.A
+	dB := a.B - b.B
+	return dL*dL + dA*dA + dB*dB
+}
@@ -0,0 +1,248 @@
+package color
+
+import (
+	"math"
+)
+
+// Lab represents a color in CIELAB space using the D65 reference white
+type Lab struct {
+	L float64
+	A float64
+	B float64
+}
+
+// D65 reference white in XYZ
+const (
+	whiteX = 0.95047
+	whiteY = 1.00000
+	whiteZ = 1.08883
+)
+
+// DistanceMetric selects how the difference between two colors is measured
+type DistanceMetric int
+
+const (
+	// CIE76 is the plain Euclidean distance in CIELAB
+	CIE76 DistanceMetric = iota
+	// CIE94 weights chroma and hue differences by the chroma of the reference color
+	CIE94
+	// CIEDE2000 is the most perceptually accurate CIE formula
+	CIEDE2000
+	// OKLabEuclidean is the Euclidean distance in OKLab
+	OKLabEuclidean
+)
+
+// String returns a human-readable name for the metric
+func (m DistanceMetric) String() string {
+	switch m {
+	case CIE76:
+		return "CIE76"
+	case CIE94:
+		return "CIE94"
+	case CIEDE2000:
+		return "CIEDE2000"
+	case OKLabEuclidean:
+		return "OKLab"
+	default:
+		return "Unknown"
+	}
+}
+
+// srgbToLinear removes the sRGB transfer function from a channel in [0, 1]
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+// linearToSRGB applies the sRGB transfer function to a linear channel in [0, 1]
+func linearToSRGB(v float64) float64 {
+	if v <= 0.0031308 {
+		return v * 12.92
+	}
+	return 1.055*math.Pow(v, 1/2.4) - 0.055
+}
+
+// linearRGB returns the linear-light channels of an sRGB color
+func linearRGB(c Color) (r, g, b float64) {
+	return srgbToLinear(float64(c.R) / 255),
+		srgbToLinear(float64(c.G) / 255),
+		srgbToLinear(float64(c.B) / 255)
+}
+
+// ToLab converts an sRGB color to CIELAB (D65)
+func (c Color) ToLab() Lab {
+	r, g, b := linearRGB(c)
+
+	x := 0.4124564*r + 0.3575761*g + 0.1804375*b
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := 0.0193339*r + 0.1191920*g + 0.9503041*b
+
+	fx := labF(x / whiteX)
+	fy := labF(y / whiteY)
+	fz := labF(z / whiteZ)
+
+	return Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+func labF(t float64) float64 {
+	const delta = 6.0 / 29.0
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29.0
+}
+
+// deltaE76 returns the CIE76 color difference
+func deltaE76(c1, c2 Lab) float64 {
+	dL := c1.L - c2.L
+	dA := c1.A - c2.A
+	dB := c1.B - c2.B
+	return math.Sqrt(dL*dL + dA*dA + dB*dB)
+}
+
+// deltaE94 returns the CIE94 color difference using graphic arts weights,
+// with c1 as the reference color
+func deltaE94(c1, c2 Lab) float64 {
+	const (
+		k1 = 0.045
+		k2 = 0.015
+	)
+
+	dL := c1.L - c2.L
+	chroma1 := math.Hypot(c1.A, c1.B)
+	chroma2 := math.Hypot(c2.A, c2.B)
+	dC := chroma1 - chroma2
+	dA := c1.A - c2.A
+	dB := c1.B - c2.B
+
+	// Hue difference is derived from the remainder so it is never negative
+	dH2 := math.Max(0, dA*dA+dB*dB-dC*dC)
+
+	sC := 1 + k1*chroma1
+	sH := 1 + k2*chroma1
+
+	return math.Sqrt(dL*dL + (dC/sC)*(dC/sC) + dH2/(sH*sH))
+}
+
+// deltaE2000 returns the CIEDE2000 color difference
+func deltaE2000(c1, c2 Lab) float64 {
+	const pow25to7 = 6103515625.0 // 25^7
+
+	chroma1 := math.Hypot(c1.A, c1.B)
+	chroma2 := math.Hypot(c2.A, c2.B)
+	meanC7 := math.Pow((chroma1+chroma2)/2, 7)
+	g := 0.5 * (1 - math.Sqrt(meanC7/(meanC7+pow25to7)))
+
+	a1 := (1 + g) * c1.A
+	a2 := (1 + g) * c2.A
+	cp1 := math.Hypot(a1, c1.B)
+	cp2 := math.Hypot(a2, c2.B)
+	hp1 := hueAngle(c1.B, a1)
+	hp2 := hueAngle(c2.B, a2)
+
+	dLp := c2.L - c1.L
+	dCp := cp2 - cp1
+
+	var dhp float64
+	if cp1*cp2 != 0 {
+		dhp = hp2 - hp1
+		if dhp > 180 {
+			dhp -= 360
+		} else if dhp < -180 {
+			dhp += 360
+		}
+	}
+	dHp := 2 * math.Sqrt(cp1*cp2) * math.Sin(degToRad(dhp/2))
+
+	meanL := (c1.L + c2.L) / 2
+	meanCp := (cp1 + cp2) / 2
+
+	// Mean hue has to account for the wraparound at 360°
+	meanHp := hp1 + hp2
+	if cp1*cp2 != 0 {
+		switch {
+		case math.Abs(hp1-hp2) <= 180:
+			meanHp /= 2
+		case meanHp < 360:
+			meanHp = (meanHp + 360) / 2
+		default:
+			meanHp = (meanHp - 360) / 2
+		}
+	}
+
+	t := 1 - 0.17*math.Cos(degToRad(meanHp-30)) +
+		0.24*math.Cos(degToRad(2*meanHp)) +
+		0.32*math.Cos(degToRad(3*meanHp+6)) -
+		0.20*math.Cos(degToRad(4*meanHp-63))
+
+	dTheta := 30 * math.Exp(-math.Pow((meanHp-275)/25, 2))
+	meanCp7 := math.Pow(meanCp, 7)
+	rC := 2 * math.Sqrt(meanCp7/(meanCp7+pow25to7))
+
+	l50 := (meanL - 50) * (meanL - 50)
+	sL := 1 + 0.015*l50/math.Sqrt(20+l50)
+	sC := 1 + 0.045*meanCp
+	sH := 1 + 0.015*meanCp*t
+	rT := -math.Sin(degToRad(2*dTheta)) * rC
+
+	lTerm := dLp / sL
+	cTerm := dCp / sC
+	hTerm := dHp / sH
+
+	return math.Sqrt(lTerm*lTerm + cTerm*cTerm + hTerm*hTerm + rT*cTerm*hTerm)
+}
+
+// deltaOK returns the Euclidean distance between two OKLab colors
+func deltaOK(c1, c2 OKLab) float64 {
+	dL := c1.L - c2.L
+	dA := c1.A - c2.A
+	dB := c1.B - c2.B
+	return math.Sqrt(dL*dL + dA*dA + dB*dB)
+}
+
+// hueAngle returns atan2(y, x) in degrees within [0, 360)
+func hueAngle(y, x float64) float64 {
+	if x == 0 && y == 0 {
+		return 0
+	}
+	h := math.Atan2(y, x) * 180 / math.Pi
+	if h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func degToRad(deg float64) float64 {
+	return deg * math.Pi / 180
+}
+
+// ToColor converts CIELAB (D65) back to sRGB, clipping out-of-gamut channels
+func (l Lab) ToColor() Color {
+	fy := (l.L + 16) / 116
+	fx := fy + l.A/500
+	fz := fy - l.B/200
+
+	x := whiteX * labFInverse(fx)
+	y := whiteY * labFInverse(fy)
+	z := whiteZ * labFInverse(fz)
+
+	return linearToColor(
+		3.2404542*x-1.5371385*y-0.4985314*z,
+		-0.9692660*x+1.8760108*y+0.0415560*z,
+		0.0556434*x-0.2040259*y+1.0572252*z,
+	)
+}
+
+func labFInverse(t float64) float64 {
+	const delta = 6.0 / 29.0
+	if t > delta {
+		return t * t * t
+	}
+	return 3 * delta * delta * (t - 4.0/29.0)
+}
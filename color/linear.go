@@ -0,0 +1,38 @@
+package color
+
+// linearTable maps each 8-bit sRGB channel value to linear light
+var linearTable = func() (t [256]float64) {
+	for i := range t {
+		t[i] = srgbToLinear(float64(i) / 255)
+	}
+	return t
+}()
+
+// linearMean accumulates a weighted mean color in linear light. Averaging
+// gamma-encoded bytes instead makes mixes of light and dark colors too dark.
+type linearMean struct {
+	weight float64
+	sum    [3]float64
+}
+
+func (m *linearMean) add(c Color, w float64) {
+	m.weight += w
+	m.sum[0] += linearTable[c.R] * w
+	m.sum[1] += linearTable[c.G] * w
+	m.sum[2] += linearTable[c.B] * w
+}
+
+func (m *linearMean) merge(o linearMean) {
+	m.weight += o.weight
+	for ch := range m.sum {
+		m.sum[ch] += o.sum[ch]
+	}
+}
+
+// color returns the mean encoded back to sRGB
+func (m linearMean) color() Color {
+	if m.weight <= 0 {
+		return Color{}
+	}
+	return linearToColor(m.sum[0]/m.weight, m.sum[1]/m.weight, m.sum[2]/m.weight)
+}
@@ -0,0 +1,61 @@
+package color
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// checkerboard returns a w x h image of alternating black and white pixels
+func checkerboard(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if (x+y)%2 == 0 {
+				img.SetNRGBA(x, y, color.NRGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				img.SetNRGBA(x, y, color.NRGBA{A: 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestAverageColorCheckerboard(t *testing.T) {
+	// Half black and half white light is 50% linear, which encodes to #BCBCBC;
+	// averaging the encoded bytes would give #808080
+	got := averageColor([]WeightedColor{
+		{Color: Color{0, 0, 0}, Weight: 1},
+		{Color: Color{255, 255, 255}, Weight: 1},
+	})
+	if want := (Color{188, 188, 188}); got != want {
+		t.Errorf("got %s, want %s", got.Hex(), want.Hex())
+	}
+}
+
+func TestExtractCheckerboardMean(t *testing.T) {
+	// A single cluster has to be the mean of every pixel
+	clusters := MedianCut{}.Extract(collectColors(checkerboard(64, 64), ExtractOptions{}), 1)
+	if len(clusters) != 1 {
+		t.Fatalf("got %d clusters, want 1", len(clusters))
+	}
+	if got, want := clusters[0].Color, (Color{188, 188, 188}); got != want {
+		t.Errorf("got %s, want %s", got.Hex(), want.Hex())
+	}
+}
+
+func TestLinearMeanWeights(t *testing.T) {
+	var a, b linearMean
+	a.add(Color{255, 0, 0}, 3)
+	b.add(Color{0, 0, 255}, 1)
+	a.merge(b)
+
+	// Three quarters red light and one quarter blue
+	want := linearToColor(0.75, 0, 0.25)
+	if got := a.color(); got != want {
+		t.Errorf("got %s, want %s", got.Hex(), want.Hex())
+	}
+	if got := (linearMean{}).color(); got != (Color{}) {
+		t.Errorf("empty mean gave %s, want black", got.Hex())
+	}
+}
@@ -0,0 +1,242 @@
+package color
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"os"
+
+	_ "golang.org/x/image/bmp"
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// LoadImage reads an image file in any supported format (JPEG, PNG, GIF,
+// WebP, BMP or TIFF) and turns it upright according to its EXIF orientation
+func LoadImage(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	img, _, err := DecodeImage(f)
+	return img, err
+}
+
+// DecodeImage decodes an image like image.Decode, with every supported format
+// registered, and applies its EXIF orientation. Images with an RGB matrix/TRC
+// ICC profile other than sRGB are converted to sRGB and returned as a
+// *ConvertedImage. It returns the format name.
+func DecodeImage(r io.Reader) (image.Image, string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image: %w", err)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Convert from the embedded color profile first, as rotating copies the pixels
+	if icc := findICCProfile(data, format); icc != nil {
+		if profile, err := ParseICCProfile(icc); err == nil && !profile.IsSRGB() {
+			img = profile.ConvertToSRGB(img)
+		}
+	}
+
+	if exif := findEXIF(data, format); exif != nil {
+		img = applyOrientation(img, exifOrientation(exif))
+	}
+	return img, format, nil
+}
+
+// findEXIF returns the TIFF-structured EXIF block of an encoded image, or nil
+func findEXIF(data []byte, format string) []byte {
+	switch format {
+	case "jpeg":
+		if segments := jpegSegments(data, 0xE1, []byte("Exif\x00\x00")); len(segments) > 0 {
+			return segments[0]
+		}
+	case "tiff":
+		return data
+	case "png":
+		return pngChunk(data, "eXIf")
+	case "webp":
+		// Some encoders keep the JPEG-style prefix inside the chunk
+		return bytes.TrimPrefix(riffChunk(data, "EXIF"), []byte("Exif\x00\x00"))
+	}
+	return nil
+}
+
+// jpegSegments returns the payloads, after prefix, of every segment with the
+// given marker that starts with prefix, in file order
+func jpegSegments(data []byte, marker byte, prefix []byte) [][]byte {
+	var segments [][]byte
+	i := 2 // Skip the SOI marker
+	for i+4 <= len(data) && data[i] == 0xFF {
+		m := data[i+1]
+		// Image data follows the start of scan; metadata always comes first
+		if m == 0xDA || m == 0xD9 {
+			break
+		}
+		length := int(binary.BigEndian.Uint16(data[i+2:]))
+		end := i + 2 + length
+		if length < 2 || end > len(data) {
+			break
+		}
+		payload := data[i+4 : end]
+		if m == marker && bytes.HasPrefix(payload, prefix) {
+			segments = append(segments, payload[len(prefix):])
+		}
+		i = end
+	}
+	return segments
+}
+
+// pngChunk returns the data of the first PNG chunk of the given type
+func pngChunk(data []byte, chunkType string) []byte {
+	i := 8 // Skip the signature
+	for i+8 <= len(data) {
+		length := int(binary.BigEndian.Uint32(data[i:]))
+		typ := string(data[i+4 : i+8])
+		end := i + 8 + length
+		if length < 0 || end+4 > len(data) || typ == "IDAT" {
+			break
+		}
+		if typ == chunkType {
+			return data[i+8 : end]
+		}
+		i = end + 4 // Skip the CRC
+	}
+	return nil
+}
+
+// riffChunk returns the data of the first top-level chunk of a RIFF file
+func riffChunk(data []byte, fourCC string) []byte {
+	i := 12 // Skip the RIFF header and form type
+	for i+8 <= len(data) {
+		length := int(binary.LittleEndian.Uint32(data[i+4:]))
+		end := i + 8 + length
+		if length < 0 || end > len(data) {
+			break
+		}
+		if string(data[i:i+4]) == fourCC {
+			return data[i+8 : end]
+		}
+		i = end + length%2 // Chunks are padded to an even size
+	}
+	return nil
+}
+
+// exifOrientation reads the Orientation tag from the first IFD of a TIFF
+// structure, returning 1 (upright) when it is missing or unreadable
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:]))
+	if ifd+2 > len(tiff) {
+		return 1
+	}
+	entries := int(order.Uint16(tiff[ifd:]))
+	for e := 0; e < entries; e++ {
+		entry := ifd + 2 + e*12
+		if entry+12 > len(tiff) {
+			break
+		}
+		const orientationTag = 0x0112
+		if order.Uint16(tiff[entry:]) == orientationTag {
+			if v := int(order.Uint16(tiff[entry+8:])); v >= 1 && v <= 8 {
+				return v
+			}
+			return 1
+		}
+	}
+	return 1
+}
+
+// applyOrientation transforms an image stored with the given EXIF
+// orientation so that it displays upright
+func applyOrientation(img image.Image, orientation int) image.Image {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	bounds := img.Bounds()
+	src := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(src, src.Bounds(), img, bounds.Min, draw.Src)
+	w, h := bounds.Dx(), bounds.Dy()
+
+	dw, dh := orientedSize(w, h, orientation)
+	dst := image.NewNRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			dx, dy := orientPoint(x, y, w, h, orientation)
+			si := src.PixOffset(x, y)
+			di := dst.PixOffset(dx, dy)
+			copy(dst.Pix[di:di+4], src.Pix[si:si+4])
+		}
+	}
+
+	// Keep track of clipped pixels through the rotation
+	if converted, ok := img.(*ConvertedImage); ok {
+		mask := make([]bool, len(converted.outOfGamut))
+		for y := 0; y < h; y++ {
+			for x := 0; x < w; x++ {
+				dx, dy := orientPoint(x, y, w, h, orientation)
+				mask[dy*dw+dx] = converted.outOfGamut[y*w+x]
+			}
+		}
+		return &ConvertedImage{NRGBA: dst, Profile: converted.Profile, outOfGamut: mask}
+	}
+	return dst
+}
+
+// orientedSize returns the size of a w x h image after reorienting it;
+// orientations 5 to 8 swap the axes
+func orientedSize(w, h, orientation int) (int, int) {
+	if orientation >= 5 {
+		return h, w
+	}
+	return w, h
+}
+
+// orientPoint maps a stored pixel position to its upright position
+func orientPoint(x, y, w, h, orientation int) (int, int) {
+	switch orientation {
+	case 2: // Mirrored horizontally
+		return w - 1 - x, y
+	case 3: // Rotated 180°
+		return w - 1 - x, h - 1 - y
+	case 4: // Mirrored vertically
+		return x, h - 1 - y
+	case 5: // Mirrored along the main diagonal
+		return y, x
+	case 6: // Needs a 90° clockwise turn
+		return h - 1 - y, x
+	case 7: // Mirrored along the anti-diagonal
+		return h - 1 - y, w - 1 - x
+	case 8: // Needs a 90° counter-clockwise turn
+		return y, w - 1 - x
+	}
+	return x, y
+}
@@ -0,0 +1,189 @@
+package color
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/jpeg"
+	"testing"
+)
+
+// cornerImage returns a 3 x 2 image with a distinct color in each corner
+//
+//	red   gray  green
+//	blue  gray  white
+func cornerImage() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 3, 2))
+	set := func(x, y int, c Color) {
+		copy(img.Pix[img.PixOffset(x, y):], []byte{c.R, c.G, c.B, 255})
+	}
+	gray := Color{128, 128, 128}
+	set(0, 0, Color{255, 0, 0})
+	set(1, 0, gray)
+	set(2, 0, Color{0, 255, 0})
+	set(0, 1, Color{0, 0, 255})
+	set(1, 1, gray)
+	set(2, 1, Color{255, 255, 255})
+	return img
+}
+
+func TestApplyOrientation(t *testing.T) {
+	red, green, blue, white := Color{255, 0, 0}, Color{0, 255, 0}, Color{0, 0, 255}, Color{255, 255, 255}
+
+	// Corners of the upright image: top left, top right, bottom left and
+	// bottom right
+	tests := []struct {
+		orientation int
+		w, h        int
+		corners     [4]Color
+	}{
+		{1, 3, 2, [4]Color{red, green, blue, white}},
+		{2, 3, 2, [4]Color{green, red, white, blue}},
+		{3, 3, 2, [4]Color{white, blue, green, red}},
+		{4, 3, 2, [4]Color{blue, white, red, green}},
+		{5, 2, 3, [4]Color{red, blue, green, white}},
+		{6, 2, 3, [4]Color{blue, red, white, green}},
+		{7, 2, 3, [4]Color{white, green, blue, red}},
+		{8, 2, 3, [4]Color{green, white, red, blue}},
+	}
+
+	src := cornerImage()
+	// Mark the red corner as clipped, as if converted from a wide gamut
+	converted := &ConvertedImage{NRGBA: src, outOfGamut: make([]bool, 6)}
+	converted.outOfGamut[0] = true
+
+	for _, tt := range tests {
+		for _, img := range []image.Image{src, converted} {
+			out := applyOrientation(img, tt.orientation)
+			if b := out.Bounds(); b.Dx() != tt.w || b.Dy() != tt.h {
+				t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), tt.w, tt.h)
+				continue
+			}
+
+			points := [4]image.Point{{0, 0}, {tt.w - 1, 0}, {0, tt.h - 1}, {tt.w - 1, tt.h - 1}}
+			for i, p := range points {
+				r, g, b, _ := out.At(p.X, p.Y).RGBA()
+				got := Color{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)}
+				if got != tt.corners[i] {
+					t.Errorf("orientation %d: pixel %v is %s, want %s", tt.orientation, p, got.Hex(), tt.corners[i].Hex())
+				}
+			}
+
+			c, ok := out.(*ConvertedImage)
+			if img == image.Image(converted) != ok {
+				t.Errorf("orientation %d: got %T from %T", tt.orientation, out, img)
+			}
+			if !ok {
+				continue
+			}
+			// Only the red pixel is clipped, wherever it ends up
+			for y := 0; y < tt.h; y++ {
+				for x := 0; x < tt.w; x++ {
+					r, g, b, _ := out.At(x, y).RGBA()
+					isRed := Color{uint8(r >> 8), uint8(g >> 8), uint8(b >> 8)} == red
+					if c.OutOfGamut(x, y) != isRed {
+						t.Errorf("orientation %d: pixel (%d, %d) clipped %v, want %v", tt.orientation, x, y, c.OutOfGamut(x, y), isRed)
+					}
+				}
+			}
+		}
+	}
+}
+
+// exifBlock builds a TIFF structure with a single Orientation entry
+func exifBlock(order binary.AppendByteOrder, orientation uint16) []byte {
+	block := []byte("II")
+	if order == binary.BigEndian {
+		block = []byte("MM")
+	}
+	block = order.AppendUint16(block, 42)
+	block = order.AppendUint32(block, 8)
+	block = order.AppendUint16(block, 1)
+	block = order.AppendUint16(block, 0x0112) // Orientation
+	block = order.AppendUint16(block, 3)      // SHORT
+	block = order.AppendUint32(block, 1)
+	block = order.AppendUint16(block, orientation)
+	block = order.AppendUint16(block, 0)
+	return order.AppendUint32(block, 0)
+}
+
+func TestExifOrientation(t *testing.T) {
+	for _, order := range []binary.AppendByteOrder{binary.LittleEndian, binary.BigEndian} {
+		for o := uint16(1); o <= 8; o++ {
+			if got := exifOrientation(exifBlock(order, o)); got != int(o) {
+				t.Errorf("%v orientation %d: got %d", order, o, got)
+			}
+		}
+	}
+
+	valid := exifBlock(binary.BigEndian, 6)
+	manyEntries := append([]byte(nil), valid...)
+	binary.BigEndian.PutUint16(manyEntries[8:], 0xFFFF)
+	farIFD := append([]byte(nil), valid...)
+	binary.BigEndian.PutUint32(farIFD[4:], 0xFFFFFFF0)
+
+	malformed := map[string][]byte{
+		"empty":                    nil,
+		"header only":              valid[:4],
+		"bad byte order":           append([]byte("XX"), valid[2:]...),
+		"IFD past the end":         farIFD,
+		"truncated IFD count":      valid[:9],
+		"truncated entry":          valid[:16],
+		"orientation out of range": exifBlock(binary.LittleEndian, 9),
+		"orientation zero":         exifBlock(binary.LittleEndian, 0),
+	}
+	for name, block := range malformed {
+		if got := exifOrientation(block); got != 1 {
+			t.Errorf("%s: got orientation %d, want 1", name, got)
+		}
+	}
+	// Cut short before the end of the Orientation entry
+	for n := 0; n < 22; n++ {
+		if got := exifOrientation(valid[:n]); got != 1 {
+			t.Errorf("truncated to %d bytes: got orientation %d, want 1", n, got)
+		}
+	}
+
+	// Entries that fit are still read when the count runs past the end
+	if got := exifOrientation(manyEntries); got != 6 {
+		t.Errorf("entry count too large: got orientation %d, want 6", got)
+	}
+}
+
+func TestMetadataWalkersTruncated(t *testing.T) {
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, cornerImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// A JPEG with an EXIF segment rotating it, cut short at every length
+	exif := append([]byte("Exif\x00\x00"), exifBlock(binary.LittleEndian, 6)...)
+	data := []byte{0xFF, 0xD8, 0xFF, 0xE1}
+	data = binary.BigEndian.AppendUint16(data, uint16(len(exif)+2))
+	data = append(append(data, exif...), encoded.Bytes()[2:]...)
+
+	if got := exifOrientation(findEXIF(data, "jpeg")); got != 6 {
+		t.Fatalf("got orientation %d, want 6", got)
+	}
+	img, _, err := DecodeImage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := img.Bounds(); b.Dx() != 2 || b.Dy() != 3 {
+		t.Errorf("decoded size %dx%d, want 2x3", b.Dx(), b.Dy())
+	}
+
+	// A segment length running past the end must not be read
+	overlong := append([]byte(nil), data...)
+	binary.BigEndian.PutUint16(overlong[4:], 0xFFFF)
+	if segments := jpegSegments(overlong, 0xE1, []byte("Exif\x00\x00")); len(segments) != 0 {
+		t.Errorf("got %d segments from an overlong one", len(segments))
+	}
+
+	for n := range data {
+		for _, format := range []string{"jpeg", "png", "webp", "tiff"} {
+			exifOrientation(findEXIF(data[:n], format))
+			findICCProfile(data[:n], format)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package color
+
+import (
+	"image"
+)
+
+// locationGridSize is the longest side of the grid used to find connected
+// regions of each swatch color
+const locationGridSize = 256
+
+// representativeLocations returns, for each palette color, the pixel nearest
+// to its centroid inside the largest connected region of pixels assigned to
+// it. Colors that no pixel is assigned to get their centroid back.
+func representativeLocations(img image.Image, opts ExtractOptions, palette []Color, centroids []image.Point) []image.Point {
+	locations := append([]image.Point(nil), centroids...)
+	bounds := img.Bounds()
+	if bounds.Empty() || len(palette) == 0 {
+		return locations
+	}
+
+	// Label a coarse grid with the nearest palette color of each pixel
+	step := max(1, ceilDiv(max(bounds.Dx(), bounds.Dy()), locationGridSize))
+	w, h := ceilDiv(bounds.Dx(), step), ceilDiv(bounds.Dy(), step)
+	labels := make([]int, w*h)
+	for i := range labels {
+		labels[i] = -1
+	}
+	nearest := make(map[Color]int)
+	scanPixels(img, bounds, step, opts.MinAlpha, func(x, y int, c Color, a uint8) {
+		idx, ok := nearest[c]
+		if !ok {
+			idx = nearestColor(c, palette)
+			nearest[c] = idx
+		}
+		labels[(y-bounds.Min.Y)/step*w+(x-bounds.Min.X)/step] = idx
+	})
+
+	// Find the largest 4-connected region of each color
+	largest := make([][]int, len(palette))
+	visited := make([]bool, len(labels))
+	for start, label := range labels {
+		if label < 0 || visited[start] {
+			continue
+		}
+
+		region := []int{start}
+		visited[start] = true
+		for i := 0; i < len(region); i++ {
+			p := region[i]
+			x, y := p%w, p/w
+			for _, n := range [4][2]int{{x - 1, y}, {x + 1, y}, {x, y - 1}, {x, y + 1}} {
+				if n[0] < 0 || n[1] < 0 || n[0] >= w || n[1] >= h {
+					continue
+				}
+				j := n[1]*w + n[0]
+				if !visited[j] && labels[j] == label {
+					visited[j] = true
+					region = append(region, j)
+				}
+			}
+		}
+
+		if len(region) > len(largest[label]) {
+			largest[label] = region
+		}
+	}
+
+	for i, region := range largest {
+		best := -1
+		bestDist := 0
+		for _, p := range region {
+			pt := image.Pt(bounds.Min.X+p%w*step, bounds.Min.Y+p/w*step)
+			d := pt.Sub(centroids[i])
+			if dist := d.X*d.X + d.Y*d.Y; best < 0 || dist < bestDist {
+				best, bestDist = p, dist
+				locations[i] = pt
+			}
+		}
+	}
+	return locations
+}
@@ -0,0 +1,184 @@
+package color
+
+import (
+	"math"
+	"sort"
+)
+
+// MedianCut quantizes by recursively splitting the color box with the largest
+// weighted error at the cut that minimizes the error of its two halves
+type MedianCut struct{}
+
+// Extract implements Extractor
+func (MedianCut) Extract(colors []WeightedColor, k int) []Cluster {
+	boxes := medianCut(colors, k)
+	clusters := make([]Cluster, 0, len(boxes))
+	for _, box := range boxes {
+		if box.weight > 0 {
+			clusters = append(clusters, Cluster{Color: averageColor(box.colors), Population: box.weight})
+		}
+	}
+	return clusters
+}
+
+// Box is a median-cut partition of the image's colors
+type Box struct {
+	colors []WeightedColor
+	weight float64
+	// Weighted mean and sum of squared deviations for each of R, G and B
+	mean     [3]float64
+	variance [3]float64
+}
+
+// error returns the box's total weighted squared error around its mean
+func (b *Box) error() float64 {
+	return b.variance[0] + b.variance[1] + b.variance[2]
+}
+
+// medianCut partitions colors into at most k boxes, repeatedly splitting the
+// box with the largest weighted error along its highest-variance channel
+func medianCut(colors []WeightedColor, k int) []*Box {
+	boxes := []*Box{createBox(colors)}
+
+	for len(boxes) < k {
+		i := findBoxToSplit(boxes)
+		if i < 0 {
+			break
+		}
+		box1, box2 := splitBox(boxes[i])
+		boxes[i] = box1
+		boxes = append(boxes, box2)
+	}
+
+	return boxes
+}
+
+func createBox(colors []WeightedColor) *Box {
+	box := &Box{colors: colors}
+
+	var sum [3]float64
+	for _, c := range colors {
+		box.weight += c.Weight
+		for ch, v := range channels(c.Color) {
+			sum[ch] += v * c.Weight
+		}
+	}
+	if box.weight == 0 {
+		return box
+	}
+
+	for ch := range sum {
+		box.mean[ch] = sum[ch] / box.weight
+	}
+	for _, c := range colors {
+		for ch, v := range channels(c.Color) {
+			d := v - box.mean[ch]
+			box.variance[ch] += d * d * c.Weight
+		}
+	}
+
+	return box
+}
+
+// channels returns a color's R, G and B values as floats
+func channels(c Color) [3]float64 {
+	return [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+}
+
+// findBoxToSplit returns the index of the splittable box with the largest
+// error, or -1 if every box holds a single color
+func findBoxToSplit(boxes []*Box) int {
+	maxIndex := -1
+	maxError := 0.0
+
+	for i, box := range boxes {
+		if len(box.colors) < 2 {
+			continue
+		}
+		if e := box.error(); e > maxError {
+			maxError = e
+			maxIndex = i
+		}
+	}
+
+	return maxIndex
+}
+
+func splitBox(box *Box) (*Box, *Box) {
+	// Split along the channel with the most variance
+	dim := 0
+	for ch := 1; ch < 3; ch++ {
+		if box.variance[ch] > box.variance[dim] {
+			dim = ch
+		}
+	}
+
+	// Sort colors along chosen dimension
+	sort.Slice(box.colors, func(i, j int) bool {
+		return channels(box.colors[i].Color)[dim] < channels(box.colors[j].Color)[dim]
+	})
+
+	// Cut where the combined squared error of the two halves is smallest,
+	// keeping at least one color on each side
+	var total moments
+	for _, c := range box.colors {
+		total.add(c)
+	}
+
+	var left moments
+	median := 1
+	bestError := math.MaxFloat64
+	for i, c := range box.colors[:len(box.colors)-1] {
+		left.add(c)
+		if e := left.error() + total.minus(left).error(); e < bestError {
+			bestError = e
+			median = i + 1
+		}
+	}
+
+	box1 := createBox(box.colors[:median])
+	box2 := createBox(box.colors[median:])
+
+	return box1, box2
+}
+
+// moments accumulates the weighted sums needed to compute a set's squared error
+type moments struct {
+	weight  float64
+	sum     [3]float64
+	squares float64
+}
+
+func (m *moments) add(c WeightedColor) {
+	m.weight += c.Weight
+	for ch, v := range channels(c.Color) {
+		m.sum[ch] += v * c.Weight
+		m.squares += v * v * c.Weight
+	}
+}
+
+func (m moments) minus(o moments) moments {
+	m.weight -= o.weight
+	for ch := range m.sum {
+		m.sum[ch] -= o.sum[ch]
+	}
+	m.squares -= o.squares
+	return m
+}
+
+// error returns the weighted sum of squared distances from the mean
+func (m moments) error() float64 {
+	if m.weight <= 0 {
+		return 0
+	}
+	return m.squares - (m.sum[0]*m.sum[0]+m.sum[1]*m.sum[1]+m.sum[2]*m.sum[2])/m.weight
+}
+
+// averageColor returns the weighted mean of the colors in linear light
+func averageColor(colors []WeightedColor) Color {
+	var mean linearMean
+	for _, c := range colors {
+		mean.add(c.Color, c.Weight)
+	}
+	return mean.color()
+}
@@ -0,0 +1,74 @@
+package color
+
+import (
+	"image"
+	"testing"
+)
+
+// skewedColors is a histogram dominated by shades of red, with a few
+// greens and a blue minority of under half a percent
+func skewedColors() []WeightedColor {
+	return []WeightedColor{
+		{Color{250, 20, 20}, 4000},
+		{Color{230, 30, 25}, 3000},
+		{Color{210, 15, 30}, 2000},
+		{Color{190, 25, 20}, 1000},
+		{Color{40, 200, 60}, 300},
+		{Color{60, 180, 40}, 200},
+		{Color{20, 40, 230}, 40},
+	}
+}
+
+func TestMedianCutKeepsMinority(t *testing.T) {
+	colors := skewedColors()
+	total := 0.0
+	for _, c := range colors {
+		total += c.Weight
+	}
+
+	for k := 4; k <= len(colors); k++ {
+		clusters := MedianCut{}.Extract(colors, k)
+		if len(clusters) != k {
+			t.Errorf("k=%d: got %d clusters", k, len(clusters))
+		}
+
+		// Every pixel has to end up in some cluster
+		sum := 0.0
+		hasBlue := false
+		for _, c := range clusters {
+			sum += c.Population
+			hasBlue = hasBlue || c.Color == (Color{20, 40, 230})
+		}
+		if sum != total {
+			t.Errorf("k=%d: clusters hold %v pixels, want %v", k, sum, total)
+		}
+		if !hasBlue {
+			t.Errorf("k=%d: blue minority lost from %v", k, clusters)
+		}
+	}
+}
+
+func TestExtractClustersSkewed(t *testing.T) {
+	// Lay the histogram out as pixels
+	colors := skewedColors()
+	total := 0
+	for _, c := range colors {
+		total += int(c.Weight)
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, total, 1))
+	i := 0
+	for _, c := range colors {
+		for n := 0; n < int(c.Weight); n++ {
+			copy(img.Pix[4*i:], []byte{c.R, c.G, c.B, 255})
+			i++
+		}
+	}
+
+	clusters := ExtractClusters(img, ExtractOptions{NumColors: 4, Workers: 1})
+	if len(clusters) != 4 {
+		t.Fatalf("got %d colors, want 4", len(clusters))
+	}
+	if blue := clusters[len(clusters)-1]; blue.Color != (Color{20, 40, 230}) || blue.Population != 40 {
+		t.Errorf("least populous color is %s with %v pixels, want the 40 blue ones", blue.Color.Hex(), blue.Population)
+	}
+}
@@ -0,0 +1,155 @@
+package color
+
+import "sort"
+
+// Octree quantizes by inserting every color into an 8-level octree on the
+// bits of R, G and B, then folding the least populated deepest nodes into
+// their parents until only k leaves remain
+type Octree struct{}
+
+const octreeDepth = 8
+
+type octreeNode struct {
+	children [8]*octreeNode
+	leaf     bool
+	mean     linearMean
+}
+
+// octreeBuilder tracks the tree's leaves and the internal nodes at each level
+type octreeBuilder struct {
+	root       *octreeNode
+	leaves     int
+	reducibles [octreeDepth][]*octreeNode
+	// sorted marks levels whose reducibles are ordered lightest first
+	sorted [octreeDepth]bool
+}
+
+// Extract implements Extractor
+func (Octree) Extract(colors []WeightedColor, k int) []Cluster {
+	if len(colors) == 0 || k < 1 {
+		return nil
+	}
+
+	t := &octreeBuilder{root: &octreeNode{}}
+	t.reducibles[0] = []*octreeNode{t.root}
+	for _, c := range colors {
+		t.insert(c)
+	}
+
+	for t.leaves > k {
+		if !t.reduce(k) {
+			break
+		}
+	}
+
+	var clusters []Cluster
+	collectOctreeLeaves(t.root, &clusters)
+	return clusters
+}
+
+func (t *octreeBuilder) insert(c WeightedColor) {
+	node := t.root
+	for level := 0; level < octreeDepth && !node.leaf; level++ {
+		shift := 7 - level
+		idx := int(c.R>>shift&1)<<2 | int(c.G>>shift&1)<<1 | int(c.B>>shift&1)
+
+		child := node.children[idx]
+		if child == nil {
+			child = &octreeNode{leaf: level == octreeDepth-1}
+			node.children[idx] = child
+			if child.leaf {
+				t.leaves++
+			} else {
+				t.reducibles[level+1] = append(t.reducibles[level+1], child)
+			}
+		}
+		node = child
+	}
+
+	node.mean.add(c.Color, c.Weight)
+}
+
+// reduce merges the children of the lightest node on the deepest level that
+// still has internal nodes, without going below k leaves. When merging them
+// all would, only the lightest children are merged together. It reports false
+// once nothing can be merged.
+func (t *octreeBuilder) reduce(k int) bool {
+	level := octreeDepth - 1
+	for level >= 0 && len(t.reducibles[level]) == 0 {
+		level--
+	}
+	if level < 0 {
+		return false
+	}
+
+	// Merging the lightest node loses the least detail. The children of a
+	// level's nodes are final by the time it is reduced, so it is sorted once.
+	nodes := t.reducibles[level]
+	if !t.sorted[level] {
+		weights := make(map[*octreeNode]float64, len(nodes))
+		for _, n := range nodes {
+			weights[n] = childWeight(n)
+		}
+		sort.SliceStable(nodes, func(i, j int) bool {
+			return weights[nodes[i]] < weights[nodes[j]]
+		})
+		t.sorted[level] = true
+	}
+	node := nodes[0]
+
+	var children []int
+	for i, child := range node.children {
+		if child != nil {
+			children = append(children, i)
+		}
+	}
+	if excess := t.leaves - k; len(children)-1 > excess {
+		// The node's children are all leaves, since deeper levels have no
+		// internal nodes left. Fold the lightest of them into one.
+		sort.Slice(children, func(a, b int) bool {
+			return node.children[children[a]].mean.weight < node.children[children[b]].mean.weight
+		})
+		into := node.children[children[0]]
+		for _, i := range children[1 : excess+1] {
+			into.mean.merge(node.children[i].mean)
+			node.children[i] = nil
+		}
+		t.leaves -= excess
+		return true
+	}
+
+	t.reducibles[level] = nodes[1:]
+	for _, i := range children {
+		node.mean.merge(node.children[i].mean)
+		node.children[i] = nil
+	}
+	node.leaf = true
+	t.leaves -= len(children) - 1
+
+	return true
+}
+
+// childWeight sums the weight held directly by a node's children
+func childWeight(n *octreeNode) float64 {
+	total := 0.0
+	for _, child := range n.children {
+		if child != nil {
+			total += child.mean.weight
+		}
+	}
+	return total
+}
+
+func collectOctreeLeaves(n *octreeNode, clusters *[]Cluster) {
+	if n.leaf {
+		if n.mean.weight > 0 {
+			*clusters = append(*clusters, Cluster{Color: n.mean.color(), Population: n.mean.weight})
+		}
+		return
+	}
+	for _, child := range n.children {
+		if child != nil {
+			collectOctreeLeaves(child, clusters)
+		}
+	}
+}
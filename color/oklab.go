@@ -0,0 +1,146 @@
+package color
+
+import (
+	"math"
+)
+
+// OKLab represents a color in Björn Ottosson's perceptual OKLab space.
+// L runs from 0 (black) to 1 (white); A and B are roughly within ±0.4.
+type OKLab struct {
+	L float64
+	A float64
+	B float64
+}
+
+// OKLCH is the cylindrical form of OKLab, with chroma C and hue H in degrees
+type OKLCH struct {
+	L float64
+	C float64
+	H float64
+}
+
+// gamutEpsilon absorbs the precision of the published OKLab matrices, well below
+// one 8-bit step, when testing linear channels against [0, 1]
+const gamutEpsilon = 1e-5
+
+// ToOKLab converts an sRGB color to OKLab
+func (c Color) ToOKLab() OKLab {
+	r, g, b := linearRGB(c)
+	return linearToOKLab(r, g, b)
+}
+
+// ToOKLCH converts an sRGB color to OKLCH
+func (c Color) ToOKLCH() OKLCH {
+	return c.ToOKLab().ToOKLCH()
+}
+
+// linearToOKLab converts linear-light sRGB channels to OKLab
+func linearToOKLab(r, g, b float64) OKLab {
+	l := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*b)
+	m := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*b)
+	s := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*b)
+
+	return OKLab{
+		L: 0.2104542553*l + 0.7936177850*m - 0.0040720468*s,
+		A: 1.9779984951*l - 2.4285922050*m + 0.4505937099*s,
+		B: 0.0259040371*l + 0.7827717662*m - 0.8086757660*s,
+	}
+}
+
+// LinearRGB converts to linear-light sRGB channels. Values outside [0, 1]
+// mean the color is outside the sRGB gamut.
+func (o OKLab) LinearRGB() (r, g, b float64) {
+	l := o.L + 0.3963377774*o.A + 0.2158037573*o.B
+	m := o.L - 0.1055613458*o.A - 0.0638541728*o.B
+	s := o.L - 0.0894841775*o.A - 1.2914855480*o.B
+
+	l, m, s = l*l*l, m*m*m, s*s*s
+
+	return 4.0767416621*l - 3.3077115913*m + 0.2309699292*s,
+		-1.2684380046*l + 2.6097574011*m - 0.3413193965*s,
+		-0.0041960863*l - 0.7034186147*m + 1.7076147010*s
+}
+
+// InGamut reports whether the color can be shown in sRGB without clipping
+func (o OKLab) InGamut() bool {
+	r, g, b := o.LinearRGB()
+	return inUnitRange(r) && inUnitRange(g) && inUnitRange(b)
+}
+
+// ToColor converts to sRGB, clipping each channel that falls outside the gamut
+func (o OKLab) ToColor() Color {
+	return linearToColor(o.LinearRGB())
+}
+
+// ToOKLCH converts OKLab to its cylindrical form
+func (o OKLab) ToOKLCH() OKLCH {
+	return OKLCH{
+		L: o.L,
+		C: math.Hypot(o.A, o.B),
+		H: hueAngle(o.B, o.A),
+	}
+}
+
+// ToOKLab converts OKLCH back to rectangular OKLab
+func (o OKLCH) ToOKLab() OKLab {
+	rad := degToRad(o.H)
+	return OKLab{
+		L: o.L,
+		A: o.C * math.Cos(rad),
+		B: o.C * math.Sin(rad),
+	}
+}
+
+// InGamut reports whether the color can be shown in sRGB without clipping
+func (o OKLCH) InGamut() bool {
+	return o.ToOKLab().InGamut()
+}
+
+// ToColor converts to sRGB, clipping each channel that falls outside the gamut
+func (o OKLCH) ToColor() Color {
+	return o.ToOKLab().ToColor()
+}
+
+// linearToColor encodes linear-light channels as an 8-bit sRGB color, clipping
+// anything outside [0, 1]
+func linearToColor(r, g, b float64) Color {
+	return Color{
+		R: encodeChannel(r),
+		G: encodeChannel(g),
+		B: encodeChannel(b),
+	}
+}
+
+func encodeChannel(v float64) uint8 {
+	return uint8(math.Round(linearToSRGB(clamp(v, 0, 1)) * 255))
+}
+
+func inUnitRange(v float64) bool {
+	return v >= -gamutEpsilon && v <= 1+gamutEpsilon
+}
+
+// MapToGamut reduces chroma, keeping lightness and hue, until the color fits
+// in sRGB. Lightness outside [0, 1] maps to black or white.
+func (o OKLCH) MapToGamut() OKLCH {
+	switch {
+	case o.L >= 1:
+		return OKLCH{L: 1, H: o.H}
+	case o.L <= 0:
+		return OKLCH{L: 0, H: o.H}
+	case o.InGamut():
+		return o
+	}
+
+	// Binary search for the largest chroma that is still displayable
+	lo, hi := 0.0, o.C
+	for hi-lo > 1e-5 {
+		mid := (lo + hi) / 2
+		if (OKLCH{L: o.L, C: mid, H: o.H}).InGamut() {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+
+	return OKLCH{L: o.L, C: lo, H: o.H}
+}
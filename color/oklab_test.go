@@ -0,0 +1,128 @@
+package color
+
+import (
+	"math"
+	"testing"
+)
+
+func TestOKLabRoundTrip(t *testing.T) {
+	var colors []Color
+	for v := 0; v < 256; v++ {
+		u := uint8(v)
+		colors = append(colors, Color{u, 0, 0}, Color{0, u, 0}, Color{0, 0, u}, Color{u, u, u})
+	}
+	colors = append(colors, randomColors(5000, 3)...)
+
+	for _, c := range colors {
+		lab := c.ToOKLab()
+		if !lab.InGamut() {
+			t.Errorf("%s: OKLab %v reported out of gamut", c.Hex(), lab)
+		}
+		if got := lab.ToColor(); got != c {
+			t.Errorf("%s: OKLab round trip gave %s", c.Hex(), got.Hex())
+		}
+		if got := c.ToOKLCH().ToColor(); got != c {
+			t.Errorf("%s: OKLCH round trip gave %s", c.Hex(), got.Hex())
+		}
+	}
+}
+
+func TestOKLCHRoundTrip(t *testing.T) {
+	for _, c := range randomColors(5000, 4) {
+		lab := c.ToOKLab()
+		lch := lab.ToOKLCH()
+		if lch.C < 0 || lch.H < 0 || lch.H >= 360 {
+			t.Fatalf("%s: OKLCH %v out of range", c.Hex(), lch)
+		}
+
+		back := lch.ToOKLab()
+		if math.Abs(back.L-lab.L) > 1e-12 || math.Abs(back.A-lab.A) > 1e-12 || math.Abs(back.B-lab.B) > 1e-12 {
+			t.Errorf("%s: OKLab %v came back as %v", c.Hex(), lab, back)
+		}
+	}
+}
+
+func TestOKLabKnownValues(t *testing.T) {
+	tests := []struct {
+		c    Color
+		want OKLab
+	}{
+		{Color{0, 0, 0}, OKLab{0, 0, 0}},
+		{Color{255, 255, 255}, OKLab{1, 0, 0}},
+		{Color{255, 0, 0}, OKLab{0.627955, 0.224863, 0.125846}},
+		{Color{0, 0, 255}, OKLab{0.452014, -0.032457, -0.311528}},
+	}
+	for _, tt := range tests {
+		got := tt.c.ToOKLab()
+		if math.Abs(got.L-tt.want.L) > 1e-4 || math.Abs(got.A-tt.want.A) > 1e-4 || math.Abs(got.B-tt.want.B) > 1e-4 {
+			t.Errorf("%s: got %v, want %v", tt.c.Hex(), got, tt.want)
+		}
+	}
+}
+
+func TestMapToGamut(t *testing.T) {
+	tests := []struct {
+		name string
+		in   OKLCH
+		want Color
+	}{
+		{"negative lightness", OKLCH{L: -0.2, C: 0.3, H: 40}, Color{0, 0, 0}},
+		{"zero lightness", OKLCH{L: 0, C: 0.1, H: 200}, Color{0, 0, 0}},
+		{"full lightness", OKLCH{L: 1, C: 0.2, H: 120}, Color{255, 255, 255}},
+		{"excess lightness", OKLCH{L: 1.3, C: 0.05, H: 300}, Color{255, 255, 255}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.in.MapToGamut()
+			if got.C != 0 {
+				t.Errorf("chroma %v, want 0", got.C)
+			}
+			if c := got.ToColor(); c != tt.want {
+				t.Errorf("got %s, want %s", c.Hex(), tt.want.Hex())
+			}
+		})
+	}
+
+	t.Run("achromatic", func(t *testing.T) {
+		for _, l := range []float64{0.01, 0.25, 0.5, 0.75, 0.99} {
+			in := OKLCH{L: l, C: 0, H: 0}
+			if !in.InGamut() {
+				t.Errorf("gray at L=%v reported out of gamut", l)
+			}
+			if got := in.MapToGamut(); got != in {
+				t.Errorf("gray at L=%v mapped to %v", l, got)
+			}
+		}
+	})
+
+	t.Run("in gamut", func(t *testing.T) {
+		for _, c := range randomColors(500, 5) {
+			in := c.ToOKLCH()
+			if got := in.MapToGamut(); got != in {
+				t.Errorf("%s: in-gamut %v mapped to %v", c.Hex(), in, got)
+			}
+		}
+	})
+
+	t.Run("out of gamut", func(t *testing.T) {
+		for h := 0.0; h < 360; h += 15 {
+			for _, l := range []float64{0.2, 0.5, 0.8} {
+				in := OKLCH{L: l, C: 0.5, H: h}
+				if in.InGamut() {
+					t.Fatalf("%v reported in gamut", in)
+				}
+				got := in.MapToGamut()
+				if !got.InGamut() {
+					t.Errorf("%v mapped to %v, still out of gamut", in, got)
+				}
+				if got.L != in.L || got.H != in.H || got.C >= in.C {
+					t.Errorf("%v mapped to %v, want same L and H and lower C", in, got)
+				}
+				// The chroma found should be close to the gamut boundary
+				if (OKLCH{L: got.L, C: got.C + 1e-3, H: got.H}).InGamut() {
+					t.Errorf("%v mapped to %v, well inside the gamut", in, got)
+				}
+			}
+		}
+	})
+}
@@ -0,0 +1,269 @@
+package color
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+)
+
+// Palette is an ordered set of named swatches along with where they came from.
+// It marshals to JSON with colors as hex codes and palette types by name, so
+// the output stays stable when the Go types change. Only palette JSON uses hex
+// codes; a bare Color keeps the default struct encoding.
+type Palette struct {
+	Swatches []Swatch
+	// Source describes where the colors came from, such as "generated" or an
+	// image URL
+	Source string
+	// Type and Base are the harmony and base color of a generated palette
+	Type    *PaletteType
+	Base    *Color
+	Created time.Time
+	// Attribution credits the source, such as a photo's copyright line
+	Attribution string
+}
+
+// Swatch is one color of a palette
+type Swatch struct {
+	Color Color
+	Name  string
+	// Optional part the color plays, such as a SwatchRole name
+	Role string
+	// Optional relative weight, such as the color's share of an image
+	Weight float64
+	// Optional opacity of the color; nil means fully opaque
+	Alpha *uint8
+}
+
+// RGBA returns the swatch's color with its opacity
+func (s Swatch) RGBA() RGBA {
+	if s.Alpha == nil {
+		return s.Color.WithAlpha(255)
+	}
+	return s.Color.WithAlpha(*s.Alpha)
+}
+
+// Sources recorded by the palette constructors
+const (
+	SourceGenerated = "generated"
+	SourceImage     = "image"
+)
+
+// Colors returns the palette's colors in order
+func (p Palette) Colors() []Color {
+	colors := make([]Color, len(p.Swatches))
+	for i, s := range p.Swatches {
+		colors[i] = s.Color
+	}
+	return colors
+}
+
+// NameColor returns the closest named color, or a description of the color
+// when nothing in the color list is close enough to be honest
+func (m *ColorMatcher) NameColor(c Color) string {
+	match, err := m.MatchColor(c.Hex())
+	if err != nil || match.Approximate {
+		return DescribeColor(c)
+	}
+	return match.Name
+}
+
+// NewGeneratedPalette generates a palette like GeneratePaletteStrict and names
+// each of its colors
+func (m *ColorMatcher) NewGeneratedPalette(base string, paletteType PaletteType, variations int, opts ...PaletteOption) (Palette, error) {
+	baseColor, err := ParseColor(base)
+	if err != nil {
+		return Palette{}, err
+	}
+	colors, err := m.generatePalette(baseColor, paletteType, variations, opts)
+	if err != nil {
+		return Palette{}, err
+	}
+
+	p := Palette{
+		Swatches: make([]Swatch, len(colors)),
+		Source:   SourceGenerated,
+		Type:     &paletteType,
+		Base:     &baseColor,
+		Created:  time.Now().UTC(),
+	}
+	for i, c := range colors {
+		p.Swatches[i] = Swatch{Color: c, Name: m.NameColor(c)}
+	}
+	return p, nil
+}
+
+// NewImagePalette extracts a palette from an image like ExtractPaletteDetailed.
+// Swatches are weighted by their share of the image and labelled with the
+// Vibrant-style role they fill, if any.
+func (m *ColorMatcher) NewImagePalette(img image.Image, opts ExtractOptions) Palette {
+	return m.NewSwatchPalette(ExtractPaletteDetailed(img, opts))
+}
+
+// NewSwatchPalette builds a palette from already extracted image swatches
+func (m *ColorMatcher) NewSwatchPalette(swatches []ImageSwatch) Palette {
+	clusters := make([]Cluster, len(swatches))
+	p := Palette{
+		Swatches: make([]Swatch, len(swatches)),
+		Source:   SourceImage,
+		Created:  time.Now().UTC(),
+	}
+	for i, s := range swatches {
+		clusters[i] = Cluster{Color: s.Color, Population: s.Fraction}
+		p.Swatches[i] = Swatch{Color: s.Color, Name: m.NameColor(s.Color), Weight: s.Fraction}
+	}
+
+	for _, rs := range AssignRoles(clusters) {
+		for i, c := range clusters {
+			if c.Color == rs.Color {
+				p.Swatches[i].Role = rs.Role.String()
+			}
+		}
+	}
+	return p
+}
+
+// NewPaletteImage returns an image configuration showing the palette's colors
+// with their hex codes, names and roles. Bars are sized by weight when any
+// swatch has one, and drawn translucent when any swatch has an alpha.
+func NewPaletteImage(p Palette) PaletteImage {
+	cfg := PaletteImage{
+		ShowHexCodes: true,
+		ShowNames:    true,
+	}
+
+	weighted, translucent := false, false
+	for _, s := range p.Swatches {
+		weighted = weighted || s.Weight > 0
+		translucent = translucent || s.Alpha != nil
+	}
+
+	for _, s := range p.Swatches {
+		cfg.Colors = append(cfg.Colors, s.Color)
+		cfg.Names = append(cfg.Names, s.Name)
+		cfg.HexCodes = append(cfg.HexCodes, s.RGBA().Hex())
+		cfg.Labels = append(cfg.Labels, s.Role)
+		if weighted {
+			cfg.Weights = append(cfg.Weights, s.Weight)
+		}
+		if translucent {
+			cfg.Alphas = append(cfg.Alphas, s.RGBA().A)
+		}
+	}
+	return cfg
+}
+
+// MarshalText encodes the palette type by name
+func (pt PaletteType) MarshalText() ([]byte, error) {
+	if pt < Complementary || pt > NeutralAccent {
+		return nil, fmt.Errorf("unknown palette type: %d", pt)
+	}
+	return []byte(pt.String()), nil
+}
+
+// UnmarshalText decodes a palette type from its name, ignoring case
+func (pt *PaletteType) UnmarshalText(text []byte) error {
+	for t := Complementary; t <= NeutralAccent; t++ {
+		if strings.EqualFold(t.String(), string(text)) {
+			*pt = t
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown palette type %q", text)
+}
+
+// hexColor is a Color that encodes as its #RRGGBB hex code. Palette JSON
+// uses it so that Color itself keeps the default struct encoding.
+type hexColor Color
+
+// MarshalText encodes the color as its #RRGGBB hex code
+func (c hexColor) MarshalText() ([]byte, error) {
+	return []byte(Color(c).Hex()), nil
+}
+
+// UnmarshalText decodes any color ParseColor accepts
+func (c *hexColor) UnmarshalText(text []byte) error {
+	parsed, err := ParseColor(string(text))
+	if err != nil {
+		return err
+	}
+	*c = hexColor(parsed)
+	return nil
+}
+
+// paletteJSON is the JSON form of a Palette
+type paletteJSON struct {
+	Swatches    []Swatch     `json:"swatches"`
+	Source      string       `json:"source,omitempty"`
+	Type        *PaletteType `json:"type,omitempty"`
+	Base        *hexColor    `json:"base,omitempty"`
+	Created     time.Time    `json:"created"`
+	Attribution string       `json:"attribution,omitempty"`
+}
+
+// MarshalJSON encodes the palette with its base color as a hex code
+func (p Palette) MarshalJSON() ([]byte, error) {
+	return json.Marshal(paletteJSON{
+		Swatches:    p.Swatches,
+		Source:      p.Source,
+		Type:        p.Type,
+		Base:        (*hexColor)(p.Base),
+		Created:     p.Created,
+		Attribution: p.Attribution,
+	})
+}
+
+// UnmarshalJSON reads the form written by MarshalJSON
+func (p *Palette) UnmarshalJSON(data []byte) error {
+	var v paletteJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*p = Palette{
+		Swatches:    v.Swatches,
+		Source:      v.Source,
+		Type:        v.Type,
+		Base:        (*Color)(v.Base),
+		Created:     v.Created,
+		Attribution: v.Attribution,
+	}
+	return nil
+}
+
+// swatchJSON is the JSON form of a Swatch
+type swatchJSON struct {
+	Color  hexColor `json:"color"`
+	Name   string   `json:"name,omitempty"`
+	Role   string   `json:"role,omitempty"`
+	Weight float64  `json:"weight,omitempty"`
+	Alpha  *uint8   `json:"alpha,omitempty"`
+}
+
+// MarshalJSON encodes the swatch with its color as a hex code
+func (s Swatch) MarshalJSON() ([]byte, error) {
+	return json.Marshal(swatchJSON{
+		Color:  hexColor(s.Color),
+		Name:   s.Name,
+		Role:   s.Role,
+		Weight: s.Weight,
+		Alpha:  s.Alpha,
+	})
+}
+
+// UnmarshalJSON reads the form written by MarshalJSON
+func (s *Swatch) UnmarshalJSON(data []byte) error {
+	var v swatchJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*s = Swatch{
+		Color:  Color(v.Color),
+		Name:   v.Name,
+		Role:   v.Role,
+		Weight: v.Weight,
+		Alpha:  v.Alpha,
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package color
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPaletteJSON(t *testing.T) {
+	typ, base, half := Triadic, Color{51, 102, 204}, uint8(128)
+	p := Palette{
+		Swatches: []Swatch{
+			{Color: Color{51, 102, 204}, Name: "Blue", Role: "Vibrant", Weight: 0.75},
+			{Color: Color{204, 51, 102}, Name: "Pink", Alpha: &half},
+		},
+		Source:      SourceGenerated,
+		Type:        &typ,
+		Base:        &base,
+		Created:     time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+		Attribution: "test",
+	}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{`"color":"#3366CC"`, `"base":"#3366CC"`, `"type":"Triadic"`, `"alpha":128`} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("palette JSON %s is missing %s", data, want)
+		}
+	}
+
+	var back Palette
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, p) {
+		t.Errorf("JSON round trip gave %+v, want %+v", back, p)
+	}
+
+	if err := json.Unmarshal([]byte(`{"swatches":[{"color":"nope"}]}`), &back); err == nil {
+		t.Error("palette with an invalid color decoded without error")
+	}
+
+	// Outside a palette, colors keep the default struct encoding
+	data, err = json.Marshal(WeightedColor{Color: base, Weight: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"R":51,"G":102,"B":204,"Weight":2}`; string(data) != want {
+		t.Errorf("weighted color JSON %s, want %s", data, want)
+	}
+}
+
+func TestSwatchAlpha(t *testing.T) {
+	half, none := uint8(128), uint8(0)
+	p := Palette{Swatches: []Swatch{
+		{Color: Color{255, 0, 0}, Name: "Red"},
+		{Color: Color{0, 0, 255}, Name: "Blue", Alpha: &half},
+		{Color: Color{0, 255, 0}, Name: "Green", Alpha: &none},
+	}}
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Palette
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	for i, s := range back.Swatches {
+		if got, want := s.RGBA(), p.Swatches[i].RGBA(); got != want {
+			t.Errorf("swatch %d: JSON round trip gave %s, want %s", i, got.Hex(), want.Hex())
+		}
+	}
+
+	cfg := NewPaletteImage(p)
+	if want := []uint8{255, 128, 0}; !reflect.DeepEqual(cfg.Alphas, want) {
+		t.Errorf("alphas %v, want %v", cfg.Alphas, want)
+	}
+	if want := []string{"#FF0000", "#0000FF80", "#00FF0000"}; !reflect.DeepEqual(cfg.HexCodes, want) {
+		t.Errorf("hex codes %v, want %v", cfg.HexCodes, want)
+	}
+
+	// Opaque palettes leave Alphas unset
+	p.Swatches = p.Swatches[:1]
+	if cfg := NewPaletteImage(p); cfg.Alphas != nil {
+		t.Errorf("opaque palette gave alphas %v", cfg.Alphas)
+	}
+}
@@ -0,0 +1,309 @@
+package color
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/colornames"
+)
+
+// ErrInvalidColor is returned, wrapped with details, for strings ParseColor cannot read
+var ErrInvalidColor = errors.New("invalid color")
+
+// ParseColor parses a color string. It accepts #RGB, #RGBA, #RRGGBB and
+// #RRGGBBAA hex codes (the # is optional), CSS named colors, and the CSS
+// rgb(), rgba(), hsl(), hsla() and oklch() functional notations in both
+// comma and space separated forms. Any alpha component is validated but
+// discarded; use ParseColorAlpha to keep it.
+func ParseColor(s string) (Color, error) {
+	c, _, err := parseColor(s)
+	return c, err
+}
+
+// parseColor parses a color string and returns its alpha in [0, 1]
+func parseColor(s string) (Color, float64, error) {
+	input := strings.ToLower(strings.TrimSpace(s))
+	if input == "" {
+		return Color{}, 0, fmt.Errorf("%w: empty string", ErrInvalidColor)
+	}
+
+	var (
+		c     Color
+		alpha float64
+		err   error
+	)
+
+	switch {
+	case strings.HasPrefix(input, "#"):
+		c, alpha, err = parseHex(input[1:])
+	case strings.HasSuffix(input, ")"):
+		c, alpha, err = parseFunction(input)
+	default:
+		if named, ok := namedColor(input); ok {
+			return named, 1, nil
+		}
+		if !isHexString(input) {
+			return Color{}, 0, fmt.Errorf("%w %q: unknown color name", ErrInvalidColor, s)
+		}
+		c, alpha, err = parseHex(input)
+	}
+
+	if err != nil {
+		return Color{}, 0, fmt.Errorf("%w %q: %v", ErrInvalidColor, s, err)
+	}
+	return c, alpha, nil
+}
+
+// Hex returns the color as an uppercase #RRGGBB string
+func (c Color) Hex() string {
+	return fmt.Sprintf("#%02X%02X%02X", c.R, c.G, c.B)
+}
+
+// namedColor looks up a CSS named color
+func namedColor(name string) (Color, bool) {
+	// rebeccapurple was added in CSS Color 4 and is missing from the SVG list
+	if name == "rebeccapurple" {
+		return Color{R: 0x66, G: 0x33, B: 0x99}, true
+	}
+	rgba, ok := colornames.Map[name]
+	if !ok {
+		return Color{}, false
+	}
+	return Color{R: rgba.R, G: rgba.G, B: rgba.B}, true
+}
+
+func isHexString(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+func parseHex(hex string) (Color, float64, error) {
+	if !isHexString(hex) {
+		return Color{}, 0, fmt.Errorf("hex code contains non-hex characters")
+	}
+
+	// Expand short forms so every channel has two digits
+	switch len(hex) {
+	case 3, 4:
+		var expanded strings.Builder
+		for _, r := range hex {
+			expanded.WriteRune(r)
+			expanded.WriteRune(r)
+		}
+		hex = expanded.String()
+	case 6, 8:
+	default:
+		return Color{}, 0, fmt.Errorf("hex code must have 3, 4, 6 or 8 digits, got %d", len(hex))
+	}
+
+	channels := make([]uint8, 0, 4)
+	for i := 0; i < len(hex); i += 2 {
+		v, err := strconv.ParseUint(hex[i:i+2], 16, 8)
+		if err != nil {
+			return Color{}, 0, fmt.Errorf("bad hex channel %q", hex[i:i+2])
+		}
+		channels = append(channels, uint8(v))
+	}
+
+	alpha := 1.0
+	if len(channels) == 4 {
+		alpha = float64(channels[3]) / 255
+	}
+	return Color{R: channels[0], G: channels[1], B: channels[2]}, alpha, nil
+}
+
+// parseFunction parses CSS functional notation such as rgb(255 0 0 / 50%)
+func parseFunction(input string) (Color, float64, error) {
+	open := strings.IndexByte(input, '(')
+	if open < 0 {
+		return Color{}, 0, fmt.Errorf("missing opening parenthesis")
+	}
+	name := strings.TrimSpace(input[:open])
+	args, alphaArg, err := splitArguments(input[open+1 : len(input)-1])
+	if err != nil {
+		return Color{}, 0, err
+	}
+	if len(args) != 3 {
+		return Color{}, 0, fmt.Errorf("%s() needs 3 components, got %d", name, len(args))
+	}
+
+	alpha := 1.0
+	if alphaArg != "" {
+		if alpha, err = parseAlpha(alphaArg); err != nil {
+			return Color{}, 0, err
+		}
+	}
+
+	var c Color
+	switch name {
+	case "rgb", "rgba":
+		c, err = parseRGBArgs(args)
+	case "hsl", "hsla":
+		c, err = parseHSLArgs(args)
+	case "oklch":
+		c, err = parseOKLCHArgs(args)
+	default:
+		return Color{}, 0, fmt.Errorf("unsupported color function %q", name)
+	}
+	if err != nil {
+		return Color{}, 0, fmt.Errorf("%s(): %v", name, err)
+	}
+	return c, alpha, nil
+}
+
+// splitArguments splits the inside of a color function into its three color
+// components and an optional alpha component
+func splitArguments(inner string) ([]string, string, error) {
+	if strings.Contains(inner, ",") {
+		// Legacy comma syntax: rgb(r, g, b) or rgba(r, g, b, a)
+		parts := strings.Split(inner, ",")
+		for i := range parts {
+			parts[i] = strings.TrimSpace(parts[i])
+			if parts[i] == "" {
+				return nil, "", fmt.Errorf("empty component")
+			}
+		}
+		if len(parts) == 4 {
+			return parts[:3], parts[3], nil
+		}
+		return parts, "", nil
+	}
+
+	// Modern space syntax: rgb(r g b) or rgb(r g b / a)
+	var alpha string
+	if slash := strings.IndexByte(inner, '/'); slash >= 0 {
+		alpha = strings.TrimSpace(inner[slash+1:])
+		inner = inner[:slash]
+		if alpha == "" {
+			return nil, "", fmt.Errorf("missing alpha after '/'")
+		}
+	}
+	return strings.Fields(inner), alpha, nil
+}
+
+// parseNumber parses a plain number or percentage. Percentages are scaled so
+// that 100% equals full.
+func parseNumber(s string, full float64) (float64, error) {
+	if strings.HasSuffix(s, "%") {
+		v, err := parseFloat(strings.TrimSuffix(s, "%"))
+		if err != nil {
+			return 0, fmt.Errorf("bad percentage %q", s)
+		}
+		return v / 100 * full, nil
+	}
+	v, err := parseFloat(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad number %q", s)
+	}
+	return v, nil
+}
+
+// parseFloat parses a finite number. strconv.ParseFloat also accepts "nan"
+// and "inf", which are not valid in CSS colors.
+func parseFloat(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, err
+	}
+	if math.IsNaN(v) || math.IsInf(v, 0) {
+		return 0, fmt.Errorf("non-finite number %q", s)
+	}
+	return v, nil
+}
+
+// parseHue parses a hue in degrees, accepting deg, rad, grad and turn units
+func parseHue(s string) (float64, error) {
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"deg", 1},
+		{"grad", 0.9},
+		{"rad", 180 / math.Pi},
+		{"turn", 360},
+	}
+
+	scale := 1.0
+	for _, u := range units {
+		if strings.HasSuffix(s, u.suffix) {
+			s = strings.TrimSuffix(s, u.suffix)
+			scale = u.scale
+			break
+		}
+	}
+
+	v, err := parseFloat(s)
+	if err != nil {
+		return 0, fmt.Errorf("bad hue %q", s)
+	}
+	h := math.Mod(v*scale, 360)
+	if h < 0 {
+		h += 360
+	}
+	return h, nil
+}
+
+func parseAlpha(s string) (float64, error) {
+	v, err := parseNumber(s, 1)
+	if err != nil {
+		return 0, fmt.Errorf("alpha: %v", err)
+	}
+	return clamp(v, 0, 1), nil
+}
+
+func parseRGBArgs(args []string) (Color, error) {
+	var channels [3]uint8
+	for i, arg := range args {
+		v, err := parseNumber(arg, 255)
+		if err != nil {
+			return Color{}, err
+		}
+		channels[i] = uint8(math.Round(clamp(v, 0, 255)))
+	}
+	return Color{R: channels[0], G: channels[1], B: channels[2]}, nil
+}
+
+func parseHSLArgs(args []string) (Color, error) {
+	h, err := parseHue(args[0])
+	if err != nil {
+		return Color{}, err
+	}
+	s, err := parseNumber(args[1], 100)
+	if err != nil {
+		return Color{}, err
+	}
+	l, err := parseNumber(args[2], 100)
+	if err != nil {
+		return Color{}, err
+	}
+	return hslToRGB(HSL{H: h, S: clamp(s, 0, 100), L: clamp(l, 0, 100)}), nil
+}
+
+func parseOKLCHArgs(args []string) (Color, error) {
+	l, err := parseNumber(args[0], 1)
+	if err != nil {
+		return Color{}, err
+	}
+	// CSS maps 100% chroma to 0.4
+	c, err := parseNumber(args[1], 0.4)
+	if err != nil {
+		return Color{}, err
+	}
+	h, err := parseHue(args[2])
+	if err != nil {
+		return Color{}, err
+	}
+
+	return OKLCH{L: clamp(l, 0, 1), C: math.Max(0, c), H: h}.ToColor(), nil
+}
+
+func clamp(v, lo, hi float64) float64 {
+	return math.Max(lo, math.Min(hi, v))
+}
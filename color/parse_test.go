@@ -0,0 +1,138 @@
+package color
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Color
+	}{
+		// Hex codes
+		{"#f00", Color{255, 0, 0}},
+		{"#F008", Color{255, 0, 0}},
+		{"#3366cc", Color{0x33, 0x66, 0xCC}},
+		{"#3366CC80", Color{0x33, 0x66, 0xCC}},
+		{"3366cc", Color{0x33, 0x66, 0xCC}},
+		{"abc", Color{0xAA, 0xBB, 0xCC}},
+		{"  #3366CC  ", Color{0x33, 0x66, 0xCC}},
+
+		// Named colors
+		{"red", Color{255, 0, 0}},
+		{"CornflowerBlue", Color{100, 149, 237}},
+		{"rebeccapurple", Color{0x66, 0x33, 0x99}},
+
+		// rgb() and rgba()
+		{"rgb(51, 102, 204)", Color{0x33, 0x66, 0xCC}},
+		{"rgba(51, 102, 204, 0.5)", Color{0x33, 0x66, 0xCC}},
+		{"rgb(51 102 204)", Color{0x33, 0x66, 0xCC}},
+		{"rgb(51 102 204 / 50%)", Color{0x33, 0x66, 0xCC}},
+		{"RGB(100%, 0%, 50%)", Color{255, 0, 128}},
+		{"rgb(300 -20 127.6)", Color{255, 0, 128}},
+
+		// hsl() and hsla()
+		{"hsl(220, 60%, 50%)", Color{0x33, 0x66, 0xCC}},
+		{"hsla(220, 60%, 50%, 0.3)", Color{0x33, 0x66, 0xCC}},
+		{"hsl(220 60% 50%)", Color{0x33, 0x66, 0xCC}},
+		{"hsl(220deg 60% 50% / 1)", Color{0x33, 0x66, 0xCC}},
+		{"hsl(0.5turn 100% 50%)", Color{0, 255, 255}},
+		{"hsl(200grad 100% 50%)", Color{0, 255, 255}},
+		{"hsl(3.14159265rad 100% 50%)", Color{0, 255, 255}},
+		{"hsl(-240 100% 50%)", Color{0, 255, 0}},
+
+		// oklch()
+		{"oklch(0.627955 0.257683 29.2339)", Color{255, 0, 0}},
+		{"oklch(62.7955% 64.42% 29.2339deg)", Color{255, 0, 0}},
+		{"oklch(1 0 0)", Color{255, 255, 255}},
+		{"oklch(0 0 0 / 0.5)", Color{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		got, err := ParseColor(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%q: got %s, want %s", tt.in, got.Hex(), tt.want.Hex())
+		}
+	}
+}
+
+func TestParseColorAlpha(t *testing.T) {
+	tests := []struct {
+		in   string
+		want uint8
+	}{
+		{"#3366CC", 255},
+		{"#3366CC80", 0x80},
+		{"#f008", 0x88},
+		{"red", 255},
+		{"rgba(51, 102, 204, 0.5)", 128},
+		{"rgb(51 102 204 / 25%)", 64},
+		{"hsl(220 60% 50% / 2)", 255},
+		{"oklch(0.5 0.1 200 / -1)", 0},
+	}
+	for _, tt := range tests {
+		got, err := ParseColorAlpha(tt.in)
+		if err != nil {
+			t.Errorf("%q: %v", tt.in, err)
+			continue
+		}
+		if got.A != tt.want {
+			t.Errorf("%q: alpha %d, want %d", tt.in, got.A, tt.want)
+		}
+	}
+}
+
+func TestParseColorErrors(t *testing.T) {
+	tests := []string{
+		"",
+		"   ",
+		"notacolor",
+		"#",
+		"#12",
+		"#12345",
+		"#1234567",
+		"#123456789",
+		"#ggg",
+		"rgb()",
+		"rgb(1, 2)",
+		"rgb(1 2 3 4)",
+		"rgb(1, , 3)",
+		"rgb(1 2 3 /)",
+		"rgb(a, b, c)",
+		"rgb(10%%, 0, 0)",
+		"rgb(1 2 3 / x)",
+		"cmyk(1 2 3)",
+		"hsl(red 50% 50%)",
+		"hsl(120 50 % 50%)",
+		"oklch(0.5 0.1 12foo)",
+		"rgb 1 2 3)",
+
+		// Non-finite numbers
+		"rgb(nan, 0, 0)",
+		"rgb(inf 0 0)",
+		"rgb(-Infinity 0 0)",
+		"rgb(0 0 0 / nan)",
+		"rgb(nan% 0% 0%)",
+		"hsl(nan 50% 50%)",
+		"hsl(infdeg 50% 50%)",
+		"hsl(120 inf% 50%)",
+		"oklch(0.7 0.1 nan)",
+		"oklch(nan 0.1 120)",
+		"oklch(0.7 +inf 120)",
+		"rgb(1e999 0 0)",
+	}
+	for _, in := range tests {
+		got, err := ParseColor(in)
+		if err == nil {
+			t.Errorf("%q: got %s, want an error", in, got.Hex())
+			continue
+		}
+		if !errors.Is(err, ErrInvalidColor) {
+			t.Errorf("%q: error %v does not wrap ErrInvalidColor", in, err)
+		}
+	}
+}
@@ -0,0 +1,217 @@
+package color
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"runtime"
+	"sync"
+)
+
+const (
+	// histogramBits is the precision per channel of the extraction histogram.
+	// Each bin keeps the mean of its colors, so little accuracy is lost.
+	histogramBits = 5
+	histogramSize = 1 << (3 * histogramBits)
+
+	// tileRows is the height of the strips the image is split into for workers
+	tileRows = 64
+)
+
+// histogram accumulates the linear-light mean color of fixed-size bins
+type histogram struct {
+	bins [histogramSize]linearMean
+}
+
+func histogramIndex(c Color) int {
+	const shift = 8 - histogramBits
+	return int(c.R>>shift)<<(2*histogramBits) | int(c.G>>shift)<<histogramBits | int(c.B>>shift)
+}
+
+func (h *histogram) add(c Color, w float64) {
+	h.bins[histogramIndex(c)].add(c, w)
+}
+
+func (h *histogram) merge(o *histogram) {
+	for i := range h.bins {
+		h.bins[i].merge(o.bins[i])
+	}
+}
+
+// colors returns the mean color and weight of every non-empty bin
+func (h *histogram) colors() []WeightedColor {
+	var colors []WeightedColor
+	for _, bin := range h.bins {
+		if bin.weight <= 0 {
+			continue
+		}
+		colors = append(colors, WeightedColor{Color: bin.color(), Weight: bin.weight})
+	}
+	return colors
+}
+
+// sampleVisitor receives each sampled pixel with its un-premultiplied color and
+// a weight equal to its opacity. worker identifies the goroutine making the
+// call so callers can keep per-worker state without locking.
+type sampleVisitor func(worker, x, y int, c Color, weight float64)
+
+// sampleImage visits the pixels of an image selected by the stride, sample
+// size and alpha options, splitting the image into strips spread over workers.
+// Pixel weights include any subject weighting the options ask for.
+func sampleImage(img image.Image, opts ExtractOptions, visit sampleVisitor) {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return
+	}
+
+	stride := max(opts.Stride, 1)
+	workers := numWorkers(opts)
+	visit = weightedVisitor(img, opts, visit)
+
+	// Each strip gets a share of the reservoir proportional to its size
+	var quotaPerRow float64
+	if opts.SampleSize > 0 {
+		sampled := float64(ceilDiv(bounds.Dx(), stride) * ceilDiv(bounds.Dy(), stride))
+		if sampled > float64(opts.SampleSize) {
+			quotaPerRow = float64(opts.SampleSize) / float64(ceilDiv(bounds.Dy(), stride))
+		}
+	}
+
+	tiles := make(chan image.Rectangle)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+			for tile := range tiles {
+				if quotaPerRow == 0 {
+					scanPixels(img, tile, stride, opts.MinAlpha, func(x, y int, c Color, a uint8) {
+						visit(worker, x, y, c, float64(a)/255)
+					})
+					continue
+				}
+
+				rows := ceilDiv(tile.Dy(), stride)
+				quota := int(quotaPerRow*float64(rows) + 0.5)
+				for _, s := range reservoirSample(img, tile, stride, opts.MinAlpha, quota) {
+					visit(worker, s.x, s.y, s.c, float64(s.a)/255)
+				}
+			}
+		}(w)
+	}
+
+	// Strips start on a stride boundary so sampling lines up across them
+	step := tileRows * stride
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		tiles <- image.Rect(bounds.Min.X, y, bounds.Max.X, min(y+step, bounds.Max.Y))
+	}
+	close(tiles)
+	wg.Wait()
+}
+
+func numWorkers(opts ExtractOptions) int {
+	if opts.Workers > 0 {
+		return opts.Workers
+	}
+	return runtime.GOMAXPROCS(0)
+}
+
+func ceilDiv(a, b int) int {
+	return (a + b - 1) / b
+}
+
+type pixelSample struct {
+	x, y int
+	c    Color
+	a    uint8
+}
+
+// reservoirSample picks up to quota pixels uniformly from a tile. The random
+// source is seeded from the tile position so results are reproducible.
+func reservoirSample(img image.Image, tile image.Rectangle, stride int, minAlpha uint8, quota int) []pixelSample {
+	if quota <= 0 {
+		return nil
+	}
+
+	rng := rand.New(rand.NewSource(int64(tile.Min.Y)))
+	samples := make([]pixelSample, 0, quota)
+	seen := 0
+	scanPixels(img, tile, stride, minAlpha, func(x, y int, c Color, a uint8) {
+		seen++
+		if len(samples) < quota {
+			samples = append(samples, pixelSample{x, y, c, a})
+		} else if j := rng.Intn(seen); j < quota {
+			samples[j] = pixelSample{x, y, c, a}
+		}
+	})
+	return samples
+}
+
+// scanPixels calls fn for every stride-th pixel in rect whose alpha is at
+// least minAlpha, with the color un-premultiplied. Pixel buffers are read
+// directly for common image types.
+func scanPixels(img image.Image, rect image.Rectangle, stride int, minAlpha uint8, fn func(x, y int, c Color, a uint8)) {
+	// Fully transparent pixels carry no color
+	if minAlpha == 0 {
+		minAlpha = 1
+	}
+
+	if converted, ok := img.(*ConvertedImage); ok {
+		img = converted.NRGBA
+	}
+
+	switch src := img.(type) {
+	case *image.RGBA:
+		for y := rect.Min.Y; y < rect.Max.Y; y += stride {
+			for x := rect.Min.X; x < rect.Max.X; x += stride {
+				i := src.PixOffset(x, y)
+				p := src.Pix[i : i+4 : i+4]
+				if p[3] >= minAlpha {
+					fn(x, y, unpremultiply(p[0], p[1], p[2], p[3]), p[3])
+				}
+			}
+		}
+
+	case *image.NRGBA:
+		for y := rect.Min.Y; y < rect.Max.Y; y += stride {
+			for x := rect.Min.X; x < rect.Max.X; x += stride {
+				i := src.PixOffset(x, y)
+				p := src.Pix[i : i+4 : i+4]
+				if p[3] >= minAlpha {
+					fn(x, y, Color{R: p[0], G: p[1], B: p[2]}, p[3])
+				}
+			}
+		}
+
+	case *image.YCbCr:
+		for y := rect.Min.Y; y < rect.Max.Y; y += stride {
+			for x := rect.Min.X; x < rect.Max.X; x += stride {
+				yi := src.YOffset(x, y)
+				ci := src.COffset(x, y)
+				r, g, b := color.YCbCrToRGB(src.Y[yi], src.Cb[ci], src.Cr[ci])
+				fn(x, y, Color{R: r, G: g, B: b}, 255)
+			}
+		}
+
+	default:
+		for y := rect.Min.Y; y < rect.Max.Y; y += stride {
+			for x := rect.Min.X; x < rect.Max.X; x += stride {
+				nrgba := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+				if nrgba.A >= minAlpha {
+					fn(x, y, Color{R: nrgba.R, G: nrgba.G, B: nrgba.B}, nrgba.A)
+				}
+			}
+		}
+	}
+}
+
+// unpremultiply recovers straight 8-bit channels from alpha-premultiplied ones
+func unpremultiply(r, g, b, a uint8) Color {
+	if a == 255 {
+		return Color{R: r, G: g, B: b}
+	}
+	scale := func(v uint8) uint8 {
+		return uint8(min(int((uint32(v)*255+uint32(a)/2)/uint32(a)), 255))
+	}
+	return Color{R: scale(r), G: scale(g), B: scale(b)}
+}
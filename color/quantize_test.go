@@ -0,0 +1,61 @@
+package color
+
+import (
+	"image"
+	"testing"
+)
+
+var allExtractors = []struct {
+	name      string
+	extractor Extractor
+}{
+	{"MedianCut", MedianCut{}},
+	{"KMeans", KMeans{Seed: 1}},
+	{"Octree", Octree{}},
+	{"Wu", Wu{}},
+}
+
+// weightColors gives each color a weight of one
+func weightColors(colors []Color) []WeightedColor {
+	weighted := make([]WeightedColor, len(colors))
+	for i, c := range colors {
+		weighted[i] = WeightedColor{Color: c, Weight: 1}
+	}
+	return weighted
+}
+
+func TestExtractorsReturnK(t *testing.T) {
+	colors := weightColors(randomColors(2000, 7))
+	for _, e := range allExtractors {
+		t.Run(e.name, func(t *testing.T) {
+			for k := 1; k <= 10; k++ {
+				clusters := e.extractor.Extract(colors, k)
+				if len(clusters) != k {
+					t.Errorf("k=%d: got %d clusters", k, len(clusters))
+				}
+
+				total := 0.0
+				for _, c := range clusters {
+					total += c.Population
+				}
+				if total < 1999.999 || total > 2000.001 {
+					t.Errorf("k=%d: populations sum to %v, want 2000", k, total)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractClustersOctree(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 50, 40))
+	for i, c := range randomColors(2000, 8) {
+		img.Pix[4*i], img.Pix[4*i+1], img.Pix[4*i+2], img.Pix[4*i+3] = c.R, c.G, c.B, 255
+	}
+
+	for _, n := range []int{2, 3, 5} {
+		clusters := ExtractClusters(img, ExtractOptions{NumColors: n, Extractor: Octree{}})
+		if len(clusters) != n {
+			t.Errorf("NumColors %d: got %d colors", n, len(clusters))
+		}
+	}
+}
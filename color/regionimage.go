@@ -0,0 +1,96 @@
+package color
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+// RegionImage represents configuration for overlaying region palettes on the
+// photo they were extracted from
+type RegionImage struct {
+	Image     image.Image // The photo the regions were extracted from
+	InputPath string      // Used to load the photo when Image is nil
+	Regions   []RegionPalette
+
+	// Optional limit on the swatches drawn per region; 0 draws them all
+	SwatchesPerRegion int
+}
+
+// Swatch sizes on the overlay, in output pixels
+const (
+	minRegionSwatch = 10.0
+	maxRegionSwatch = 56.0
+)
+
+// GenerateRegionImage draws the photo scaled to fit a square image and places
+// a row of swatches at the centroid of each region
+func GenerateRegionImage(cfg RegionImage) (image.Image, error) {
+	img := cfg.Image
+	if img == nil {
+		if cfg.InputPath == "" {
+			return nil, fmt.Errorf("no input image provided")
+		}
+		var err error
+		if img, err = LoadImage(cfg.InputPath); err != nil {
+			return nil, fmt.Errorf("failed to load input image: %w", err)
+		}
+	}
+
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("input image is empty")
+	}
+
+	dc := gg.NewContext(imageSize, imageSize)
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	// Fit the whole photo, centered, so region positions stay meaningful
+	scale := math.Min(float64(imageSize)/float64(bounds.Dx()), float64(imageSize)/float64(bounds.Dy()))
+	offsetX := (float64(imageSize) - float64(bounds.Dx())*scale) / 2
+	offsetY := (float64(imageSize) - float64(bounds.Dy())*scale) / 2
+	toCanvas := func(p image.Point) (float64, float64) {
+		return offsetX + float64(p.X-bounds.Min.X)*scale, offsetY + float64(p.Y-bounds.Min.Y)*scale
+	}
+
+	dc.Push()
+	dc.Translate(offsetX, offsetY)
+	dc.Scale(scale, scale)
+	dc.DrawImage(img, -bounds.Min.X, -bounds.Min.Y)
+	dc.Pop()
+
+	for _, region := range cfg.Regions {
+		clusters := region.Clusters
+		if cfg.SwatchesPerRegion > 0 && len(clusters) > cfg.SwatchesPerRegion {
+			clusters = clusters[:cfg.SwatchesPerRegion]
+		}
+		if len(clusters) == 0 {
+			continue
+		}
+
+		// Swatches share most of the region's narrower side
+		side := float64(min(region.Bounds.Dx(), region.Bounds.Dy())) * scale * 0.8
+		size := clamp(side/float64(len(clusters)), minRegionSwatch, maxRegionSwatch)
+		cx, cy := toCanvas(region.Centroid)
+		x := cx - size*float64(len(clusters))/2
+		y := cy - size/2
+
+		for i, cl := range clusters {
+			dc.SetColor(cl.Color.ToRGBA())
+			dc.DrawRectangle(x+float64(i)*size, y, size, size)
+			dc.Fill()
+		}
+
+		// Outline the row so it stands out from the photo behind it
+		dc.SetColor(color.White)
+		dc.SetLineWidth(2)
+		dc.DrawRectangle(x, y, size*float64(len(clusters)), size)
+		dc.Stroke()
+	}
+
+	return dc.Image(), nil
+}
@@ -0,0 +1,311 @@
+package color
+
+import (
+	"image"
+	"math"
+)
+
+// RegionPalette is the palette of one region of an image
+type RegionPalette struct {
+	// Bounds is the region's bounding box in image coordinates
+	Bounds image.Rectangle
+	// Centroid is the mean position of the region's pixels
+	Centroid image.Point
+	// Fraction is the region's share of the image's pixels
+	Fraction float64
+	// Clusters are the region's colors, most populous first
+	Clusters []Cluster
+}
+
+// RegionPalettes splits an image into a rows x cols grid and extracts k colors
+// from each cell. Palettes are returned row by row.
+func RegionPalettes(img image.Image, rows, cols, k int) []RegionPalette {
+	bounds := img.Bounds()
+	rows = min(max(rows, 1), max(bounds.Dy(), 1))
+	cols = min(max(cols, 1), max(bounds.Dx(), 1))
+	if bounds.Empty() {
+		return nil
+	}
+
+	area := float64(bounds.Dx() * bounds.Dy())
+	regions := make([]RegionPalette, 0, rows*cols)
+	for r := 0; r < rows; r++ {
+		for c := 0; c < cols; c++ {
+			cell := image.Rect(
+				bounds.Min.X+c*bounds.Dx()/cols,
+				bounds.Min.Y+r*bounds.Dy()/rows,
+				bounds.Min.X+(c+1)*bounds.Dx()/cols,
+				bounds.Min.Y+(r+1)*bounds.Dy()/rows,
+			)
+			regions = append(regions, RegionPalette{
+				Bounds:   cell,
+				Centroid: image.Pt((cell.Min.X+cell.Max.X)/2, (cell.Min.Y+cell.Max.Y)/2),
+				Fraction: float64(cell.Dx()*cell.Dy()) / area,
+				Clusters: ExtractClusters(subImage(img, cell), ExtractOptions{NumColors: k}),
+			})
+		}
+	}
+	return regions
+}
+
+type subImager interface {
+	SubImage(r image.Rectangle) image.Image
+}
+
+// croppedImage limits an image without a SubImage method to a rectangle
+type croppedImage struct {
+	image.Image
+	rect image.Rectangle
+}
+
+func (c croppedImage) Bounds() image.Rectangle {
+	return c.rect
+}
+
+func subImage(img image.Image, r image.Rectangle) image.Image {
+	if s, ok := img.(subImager); ok {
+		return s.SubImage(r)
+	}
+	return croppedImage{Image: img, rect: r}
+}
+
+const (
+	// slicWorkSize is the longest side of the grid superpixels are computed on
+	slicWorkSize = 400
+	// slicCompactness trades color similarity against spatial closeness;
+	// higher values give more regular superpixels
+	slicCompactness = 10.0
+	slicIterations  = 10
+)
+
+// slicPixel is one point of the working grid
+type slicPixel struct {
+	lab    Lab
+	color  Color
+	weight float64
+}
+
+// slicCenter is a superpixel center in Lab and working grid coordinates
+type slicCenter struct {
+	lab  Lab
+	x, y float64
+}
+
+// SuperpixelPalettes segments an image into about segments SLIC superpixels,
+// regions of similar color that follow edges in the image, and extracts k
+// colors from each. Very large images are segmented at reduced resolution.
+func SuperpixelPalettes(img image.Image, segments, k int) []RegionPalette {
+	bounds := img.Bounds()
+	if bounds.Empty() {
+		return nil
+	}
+
+	// Work on every step-th pixel so the cost does not grow with image size
+	step := max(1, ceilDiv(max(bounds.Dx(), bounds.Dy()), slicWorkSize))
+	w, h := ceilDiv(bounds.Dx(), step), ceilDiv(bounds.Dy(), step)
+	pixels := make([]slicPixel, w*h)
+	scanPixels(img, bounds, step, 0, func(x, y int, c Color, a uint8) {
+		i := (y-bounds.Min.Y)/step*w + (x-bounds.Min.X)/step
+		pixels[i] = slicPixel{lab: c.ToLab(), color: c, weight: float64(a) / 255}
+	})
+
+	segments = min(max(segments, 1), w*h)
+	labels, count := slic(pixels, w, h, segments)
+
+	// Gather each superpixel's colors and extent
+	type segment struct {
+		colors     map[Color]float64
+		bounds     image.Rectangle
+		sumX, sumY float64
+		weight     float64
+	}
+	segs := make([]segment, count)
+	total := 0.0
+	for i, p := range pixels {
+		if p.weight == 0 {
+			continue
+		}
+		s := &segs[labels[i]]
+		if s.colors == nil {
+			s.colors = make(map[Color]float64)
+		}
+		s.colors[p.color] += p.weight
+
+		x := bounds.Min.X + i%w*step
+		y := bounds.Min.Y + i/w*step
+		cell := image.Rect(x, y, x+step, y+step).Intersect(bounds)
+		s.bounds = s.bounds.Union(cell)
+		s.sumX += float64(x+cell.Dx()/2) * p.weight
+		s.sumY += float64(y+cell.Dy()/2) * p.weight
+		s.weight += p.weight
+		total += p.weight
+	}
+
+	var regions []RegionPalette
+	for _, s := range segs {
+		if s.weight == 0 {
+			continue
+		}
+		colors := make([]WeightedColor, 0, len(s.colors))
+		for c, wt := range s.colors {
+			colors = append(colors, WeightedColor{Color: c, Weight: wt})
+		}
+		regions = append(regions, RegionPalette{
+			Bounds:   s.bounds,
+			Centroid: image.Pt(int(math.Round(s.sumX/s.weight)), int(math.Round(s.sumY/s.weight))),
+			Fraction: s.weight / total,
+			Clusters: clusterHistogram(colors, ExtractOptions{NumColors: k}),
+		})
+	}
+	return regions
+}
+
+// slic runs simple linear iterative clustering over a w x h grid and returns
+// a connected label for every pixel along with the number of labels
+func slic(pixels []slicPixel, w, h, segments int) ([]int, int) {
+	s := math.Sqrt(float64(w*h) / float64(segments))
+	gridStep := max(1, int(math.Round(s)))
+
+	// Seed centers on a regular grid, nudged off edges to the lowest gradient.
+	// Strips thinner than the grid step still get a row or column of seeds.
+	var centers []slicCenter
+	for y := min(gridStep/2, h-1); y < h; y += gridStep {
+		for x := min(gridStep/2, w-1); x < w; x += gridStep {
+			bx, by := lowestGradient(pixels, w, h, x, y)
+			centers = append(centers, slicCenter{lab: pixels[by*w+bx].lab, x: float64(bx), y: float64(by)})
+		}
+	}
+
+	labels := make([]int, len(pixels))
+	distances := make([]float64, len(pixels))
+	spatialScale := slicCompactness / s
+	radius := 2 * gridStep
+
+	for iter := 0; iter < slicIterations; iter++ {
+		for i := range distances {
+			distances[i] = math.Inf(1)
+		}
+
+		for ci, c := range centers {
+			cx, cy := int(c.x), int(c.y)
+			for y := max(0, cy-radius); y < min(h, cy+radius+1); y++ {
+				for x := max(0, cx-radius); x < min(w, cx+radius+1); x++ {
+					i := y*w + x
+					dc := labDistanceSq(pixels[i].lab, c.lab)
+					dx, dy := float64(x)-c.x, float64(y)-c.y
+					d := dc + (dx*dx+dy*dy)*spatialScale*spatialScale
+					if d < distances[i] {
+						distances[i] = d
+						labels[i] = ci
+					}
+				}
+			}
+		}
+
+		// Move each center to the mean of its pixels
+		type sum struct {
+			lab     Lab
+			x, y, n float64
+		}
+		sums := make([]sum, len(centers))
+		for i, l := range labels {
+			p := pixels[i]
+			sums[l].lab.L += p.lab.L
+			sums[l].lab.A += p.lab.A
+			sums[l].lab.B += p.lab.B
+			sums[l].x += float64(i % w)
+			sums[l].y += float64(i / w)
+			sums[l].n++
+		}
+		for ci, sm := range sums {
+			if sm.n == 0 {
+				continue
+			}
+			centers[ci] = slicCenter{
+				lab: Lab{L: sm.lab.L / sm.n, A: sm.lab.A / sm.n, B: sm.lab.B / sm.n},
+				x:   sm.x / sm.n,
+				y:   sm.y / sm.n,
+			}
+		}
+	}
+
+	return enforceConnectivity(labels, w, h, gridStep*gridStep/4)
+}
+
+// lowestGradient returns the position in the 3x3 neighbourhood of (x, y)
+// with the smallest color gradient, so seeds do not start on an edge
+func lowestGradient(pixels []slicPixel, w, h, x, y int) (int, int) {
+	bestX, bestY := x, y
+	best := math.Inf(1)
+	for ny := max(1, y-1); ny <= min(h-2, y+1); ny++ {
+		for nx := max(1, x-1); nx <= min(w-2, x+1); nx++ {
+			g := labDistanceSq(pixels[ny*w+nx+1].lab, pixels[ny*w+nx-1].lab) +
+				labDistanceSq(pixels[(ny+1)*w+nx].lab, pixels[(ny-1)*w+nx].lab)
+			if g < best {
+				best, bestX, bestY = g, nx, ny
+			}
+		}
+	}
+	return bestX, bestY
+}
+
+// enforceConnectivity relabels pixels so every label is one connected region.
+// Fragments smaller than minSize join the region next to them.
+func enforceConnectivity(labels []int, w, h, minSize int) ([]int, int) {
+	out := make([]int, len(labels))
+	for i := range out {
+		out[i] = -1
+	}
+
+	neighbours := func(i int) []int {
+		x, y := i%w, i/w
+		var n []int
+		if x > 0 {
+			n = append(n, i-1)
+		}
+		if x < w-1 {
+			n = append(n, i+1)
+		}
+		if y > 0 {
+			n = append(n, i-w)
+		}
+		if y < h-1 {
+			n = append(n, i+w)
+		}
+		return n
+	}
+
+	next := 0
+	for start := range labels {
+		if out[start] >= 0 {
+			continue
+		}
+
+		adjacent := -1
+		for _, n := range neighbours(start) {
+			if out[n] >= 0 {
+				adjacent = out[n]
+			}
+		}
+
+		component := []int{start}
+		out[start] = next
+		for i := 0; i < len(component); i++ {
+			for _, n := range neighbours(component[i]) {
+				if out[n] < 0 && labels[n] == labels[start] {
+					out[n] = next
+					component = append(component, n)
+				}
+			}
+		}
+
+		if len(component) < minSize && adjacent >= 0 {
+			for _, p := range component {
+				out[p] = adjacent
+			}
+			continue
+		}
+		next++
+	}
+	return out, next
+}
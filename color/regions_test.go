@@ -0,0 +1,58 @@
+package color
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// stripeImage returns a w x h image split into a red left half and a blue
+// right half
+func stripeImage(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.NRGBA{R: 220, G: 30, B: 30, A: 255}
+			if x >= w/2 {
+				c = color.NRGBA{R: 30, G: 30, B: 220, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestSuperpixelPalettesStrips(t *testing.T) {
+	tests := []struct {
+		name     string
+		w, h     int
+		segments int
+	}{
+		{"wide", 1000, 10, 4},
+		{"tall", 10, 1000, 4},
+		{"single row", 500, 1, 8},
+		{"single column", 1, 500, 8},
+		{"tiny", 2, 2, 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			regions := SuperpixelPalettes(stripeImage(tt.w, tt.h), tt.segments, 3)
+			if len(regions) == 0 {
+				t.Fatal("no regions returned")
+			}
+
+			total := 0.0
+			for _, r := range regions {
+				if len(r.Clusters) == 0 {
+					t.Errorf("region %v has no colors", r.Bounds)
+				}
+				total += r.Fraction
+			}
+			if math.Abs(total-1) > 1e-9 {
+				t.Errorf("region fractions sum to %v, want 1", total)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package color
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+)
+
+// RGBA is a Color with straight, non-premultiplied alpha. A is 255 for fully
+// opaque colors.
+type RGBA struct {
+	Color
+	A uint8
+}
+
+// WithAlpha returns the color with the given opacity
+func (c Color) WithAlpha(a uint8) RGBA {
+	return RGBA{Color: c, A: a}
+}
+
+// ParseColorAlpha parses a color string like ParseColor but keeps its alpha
+// component. Colors without one are fully opaque.
+func ParseColorAlpha(s string) (RGBA, error) {
+	c, alpha, err := parseColor(s)
+	if err != nil {
+		return RGBA{}, err
+	}
+	return c.WithAlpha(uint8(math.Round(alpha * 255))), nil
+}
+
+// Opaque reports whether the color has no transparency
+func (c RGBA) Opaque() bool {
+	return c.A == 255
+}
+
+// Hex returns the color as an uppercase #RRGGBB string when it is opaque and
+// as #RRGGBBAA otherwise
+func (c RGBA) Hex() string {
+	if c.Opaque() {
+		return c.Color.Hex()
+	}
+	return fmt.Sprintf("#%02X%02X%02X%02X", c.R, c.G, c.B, c.A)
+}
+
+// RGBA implements image/color.Color, returning alpha-premultiplied channels
+func (c RGBA) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA{R: c.R, G: c.G, B: c.B, A: c.A}.RGBA()
+}
+
+// Over composites the color onto an opaque background in gamma space, the
+// way browsers and image editors blend
+func (c RGBA) Over(bg Color) Color {
+	blend := func(fg, bg uint8) uint8 {
+		return roundChannel((float64(fg)*float64(c.A) + float64(bg)*float64(255-c.A)) / 255)
+	}
+	return Color{R: blend(c.R, bg.R), G: blend(c.G, bg.G), B: blend(c.B, bg.B)}
+}
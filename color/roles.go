@@ -0,0 +1,204 @@
+package color
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// SwatchRole is the part a color plays in an image, following the targets of
+// Android's Palette library and Vibrant.js
+type SwatchRole int
+
+const (
+	Vibrant SwatchRole = iota
+	LightVibrant
+	DarkVibrant
+	Muted
+	LightMuted
+	DarkMuted
+)
+
+// String returns a human-readable name for the role
+func (r SwatchRole) String() string {
+	switch r {
+	case Vibrant:
+		return "Vibrant"
+	case LightVibrant:
+		return "Light Vibrant"
+	case DarkVibrant:
+		return "Dark Vibrant"
+	case Muted:
+		return "Muted"
+	case LightMuted:
+		return "Light Muted"
+	case DarkMuted:
+		return "Dark Muted"
+	default:
+		return "Unknown"
+	}
+}
+
+// RoleSwatch is an image color chosen for a role, with text colors that are
+// legible on top of it
+type RoleSwatch struct {
+	Role       SwatchRole
+	Color      Color
+	Population float64
+	// TitleTextColor and BodyTextColor are white or black at the lowest
+	// opacity that reaches a contrast of 3:1 and 4.5:1 respectively
+	TitleTextColor RGBA
+	BodyTextColor  RGBA
+}
+
+// roleTarget describes the HSL saturation and lightness a role looks for,
+// both in [0, 1]
+type roleTarget struct {
+	role                      SwatchRole
+	minSat, targetSat, maxSat float64
+	minL, targetL, maxL       float64
+}
+
+// roleTargets are tried in this order, and each color fills at most one role
+var roleTargets = []roleTarget{
+	{LightVibrant, 0.35, 1, 1, 0.55, 0.74, 1},
+	{Vibrant, 0.35, 1, 1, 0.3, 0.5, 0.7},
+	{DarkVibrant, 0.35, 1, 1, 0, 0.26, 0.45},
+	{LightMuted, 0, 0.3, 0.4, 0.55, 0.74, 1},
+	{Muted, 0, 0.3, 0.4, 0.3, 0.5, 0.7},
+	{DarkMuted, 0, 0.3, 0.4, 0, 0.26, 0.45},
+}
+
+// Relative importance of matching saturation, lightness and population
+const (
+	roleSaturationWeight = 0.24
+	roleLightnessWeight  = 0.52
+	rolePopulationWeight = 0.24
+)
+
+// Minimum WCAG contrast ratios for title and body text
+const (
+	minTitleContrast = 3.0
+	minBodyContrast  = 4.5
+)
+
+// defaultRoleColors is how many clusters role extraction picks from
+const defaultRoleColors = 16
+
+// ExtractRoles extracts an image's clusters and picks a swatch for each role.
+// NumColors defaults to 16 for role extraction. Roles that no color fits are
+// left out.
+func ExtractRoles(img image.Image, opts ExtractOptions) []RoleSwatch {
+	if opts.NumColors == 0 {
+		opts.NumColors = defaultRoleColors
+	}
+	return AssignRoles(ExtractClusters(img, opts))
+}
+
+// AssignRoles picks the best cluster for each role, scoring how close its
+// saturation and lightness are to the role's target and how populous it is.
+// Swatches are returned in role order.
+func AssignRoles(clusters []Cluster) []RoleSwatch {
+	maxPopulation := 0.0
+	for _, c := range clusters {
+		maxPopulation = math.Max(maxPopulation, c.Population)
+	}
+
+	used := make([]bool, len(clusters))
+	var swatches []RoleSwatch
+	for _, target := range roleTargets {
+		best := -1
+		bestScore := 0.0
+		for i, c := range clusters {
+			if used[i] {
+				continue
+			}
+			hsl := rgbToHSL(c.Color)
+			s, l := hsl.S/100, hsl.L/100
+			if s < target.minSat || s > target.maxSat || l < target.minL || l > target.maxL {
+				continue
+			}
+
+			score := roleSaturationWeight*(1-math.Abs(s-target.targetSat)) +
+				roleLightnessWeight*(1-math.Abs(l-target.targetL))
+			if maxPopulation > 0 {
+				score += rolePopulationWeight * c.Population / maxPopulation
+			}
+			if best < 0 || score > bestScore {
+				best, bestScore = i, score
+			}
+		}
+		if best < 0 {
+			continue
+		}
+
+		used[best] = true
+		c := clusters[best]
+		swatches = append(swatches, RoleSwatch{
+			Role:           target.role,
+			Color:          c.Color,
+			Population:     c.Population,
+			TitleTextColor: textColor(c.Color, minTitleContrast),
+			BodyTextColor:  textColor(c.Color, minBodyContrast),
+		})
+	}
+
+	// Report swatches in role order rather than the order they were found
+	sort.Slice(swatches, func(i, j int) bool {
+		return swatches[i].Role < swatches[j].Role
+	})
+	return swatches
+}
+
+// RelativeLuminance returns the WCAG relative luminance of a color
+func RelativeLuminance(c Color) float64 {
+	r, g, b := linearRGB(c)
+	return 0.2126*r + 0.7152*g + 0.0722*b
+}
+
+// ContrastRatio returns the WCAG contrast ratio between two colors, from 1 to 21
+func ContrastRatio(a, b Color) float64 {
+	la, lb := RelativeLuminance(a), RelativeLuminance(b)
+	if la < lb {
+		la, lb = lb, la
+	}
+	return (la + 0.05) / (lb + 0.05)
+}
+
+// textColor returns white, or black if white cannot reach the contrast, at
+// the lowest opacity that does. If neither can, it returns whichever opaque
+// color contrasts more.
+func textColor(bg Color, minContrast float64) RGBA {
+	white := Color{R: 255, G: 255, B: 255}
+	black := Color{}
+
+	if a, ok := minTextAlpha(white, bg, minContrast); ok {
+		return white.WithAlpha(a)
+	}
+	if a, ok := minTextAlpha(black, bg, minContrast); ok {
+		return black.WithAlpha(a)
+	}
+	if ContrastRatio(white, bg) >= ContrastRatio(black, bg) {
+		return white.WithAlpha(255)
+	}
+	return black.WithAlpha(255)
+}
+
+// minTextAlpha finds the lowest opacity at which fg drawn over bg reaches the
+// contrast, by binary search since contrast grows with opacity
+func minTextAlpha(fg, bg Color, minContrast float64) (uint8, bool) {
+	if ContrastRatio(fg, bg) < minContrast {
+		return 0, false
+	}
+
+	lo, hi := 0, 255
+	for lo < hi {
+		mid := (lo + hi) / 2
+		if ContrastRatio(fg.WithAlpha(uint8(mid)).Over(bg), bg) >= minContrast {
+			hi = mid
+		} else {
+			lo = mid + 1
+		}
+	}
+	return uint8(lo), true
+}
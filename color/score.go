@@ -0,0 +1,167 @@
+package color
+
+import (
+	"fmt"
+	"math"
+)
+
+// PaletteScore rates a palette on several criteria, each from 0 (poor) to 1
+// (good), and combines them into Total
+type PaletteScore struct {
+	// Distinctness rewards a large smallest CIEDE2000 difference between any
+	// two colors, so no swatch looks like a near-duplicate of another
+	Distinctness float64
+	// LightnessSpread rewards a wide range of OKLCH lightness
+	LightnessSpread float64
+	// ChromaBalance penalizes muddy colors, which are neither clearly neutral
+	// nor clearly colorful, and palettes with no colorful color at all
+	ChromaBalance float64
+	// HarmonyFit rewards hues that sit at multiples of 30° from the base hue,
+	// the angles color harmonies are built from
+	HarmonyFit float64
+	// ContrastAvailability rewards having a pair of colors with enough WCAG
+	// contrast for body text
+	ContrastAvailability float64
+	Total                float64
+}
+
+// Relative importance of each criterion in the total score
+const (
+	distinctnessWeight    = 0.3
+	lightnessSpreadWeight = 0.2
+	chromaBalanceWeight   = 0.2
+	harmonyFitWeight      = 0.1
+	contrastWeight        = 0.2
+)
+
+// Reference values at which a criterion scores full marks
+const (
+	scoreDeltaE         = 25.0 // Smallest ΔE between any two colors
+	scoreLightnessRange = 0.6  // OKLCH lightness range
+	scoreHueTolerance   = 15.0 // Degrees off the nearest harmonic angle that score 0
+)
+
+// OKLCH chroma bands used by ChromaBalance. Colors below neutralChroma read
+// as gray and those from vividChroma up as clearly colorful; in between is muddy.
+const (
+	neutralChroma = 0.02
+	vividChroma   = 0.08
+)
+
+// ScorePalette rates a palette. The base hue for HarmonyFit is the palette's
+// Base when set, and its most colorful color otherwise.
+func ScorePalette(p Palette) PaletteScore {
+	colors := p.Colors()
+	if len(colors) == 0 {
+		return PaletteScore{}
+	}
+
+	lchs := make([]OKLCH, len(colors))
+	for i, c := range colors {
+		lchs[i] = c.ToOKLCH()
+	}
+
+	s := PaletteScore{
+		Distinctness:         scoreDistinctness(colors),
+		LightnessSpread:      scoreLightnessSpread(lchs),
+		ChromaBalance:        scoreChromaBalance(lchs),
+		ContrastAvailability: scoreContrast(colors),
+	}
+
+	base := lchs[0]
+	if p.Base != nil {
+		base = p.Base.ToOKLCH()
+	} else {
+		for _, lch := range lchs {
+			if lch.C > base.C {
+				base = lch
+			}
+		}
+	}
+	s.HarmonyFit = scoreHarmonyFit(lchs, base.H)
+
+	s.Total = distinctnessWeight*s.Distinctness +
+		lightnessSpreadWeight*s.LightnessSpread +
+		chromaBalanceWeight*s.ChromaBalance +
+		harmonyFitWeight*s.HarmonyFit +
+		contrastWeight*s.ContrastAvailability
+	return s
+}
+
+// String formats the score with its breakdown, for logging
+func (s PaletteScore) String() string {
+	return fmt.Sprintf("%.2f (distinctness %.2f, lightness spread %.2f, chroma balance %.2f, harmony fit %.2f, contrast %.2f)",
+		s.Total, s.Distinctness, s.LightnessSpread, s.ChromaBalance, s.HarmonyFit, s.ContrastAvailability)
+}
+
+func scoreDistinctness(colors []Color) float64 {
+	if len(colors) < 2 {
+		return 1
+	}
+	labs := make([]Lab, len(colors))
+	for i, c := range colors {
+		labs[i] = c.ToLab()
+	}
+
+	smallest := math.Inf(1)
+	for i := range labs {
+		for j := i + 1; j < len(labs); j++ {
+			smallest = math.Min(smallest, deltaE2000(labs[i], labs[j]))
+		}
+	}
+	return clamp(smallest/scoreDeltaE, 0, 1)
+}
+
+func scoreLightnessSpread(lchs []OKLCH) float64 {
+	lo, hi := 1.0, 0.0
+	for _, lch := range lchs {
+		lo = math.Min(lo, lch.L)
+		hi = math.Max(hi, lch.L)
+	}
+	return clamp((hi-lo)/scoreLightnessRange, 0, 1)
+}
+
+func scoreChromaBalance(lchs []OKLCH) float64 {
+	total, most := 0.0, 0.0
+	for _, lch := range lchs {
+		most = math.Max(most, lch.C)
+
+		// Muddiness peaks halfway between the neutral and vivid bands
+		mid := (neutralChroma + vividChroma) / 2
+		if lch.C > neutralChroma && lch.C < vividChroma {
+			total += math.Abs(lch.C-mid) / (mid - neutralChroma)
+		} else {
+			total++
+		}
+	}
+	return total / float64(len(lchs)) * clamp(most/vividChroma, 0, 1)
+}
+
+func scoreHarmonyFit(lchs []OKLCH, baseHue float64) float64 {
+	// Grays have no meaningful hue, so each color counts by how colorful it is
+	total, weights := 0.0, 0.0
+	for _, lch := range lchs {
+		w := clamp(lch.C/vividChroma, 0, 1)
+		if w == 0 {
+			continue
+		}
+		offset := math.Mod(rotateDegrees(lch.H, -baseHue), 30)
+		off := math.Min(offset, 30-offset)
+		total += w * clamp(1-off/scoreHueTolerance, 0, 1)
+		weights += w
+	}
+	if weights == 0 {
+		return 1
+	}
+	return total / weights
+}
+
+func scoreContrast(colors []Color) float64 {
+	best := 1.0
+	for i, c := range colors {
+		for _, other := range colors[i+1:] {
+			best = math.Max(best, ContrastRatio(c, other))
+		}
+	}
+	return clamp((best-1)/(minBodyContrast-1), 0, 1)
+}
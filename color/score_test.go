@@ -0,0 +1,62 @@
+package color
+
+import "testing"
+
+// swatchPalette builds a palette from hex codes
+func swatchPalette(t *testing.T, hexes ...string) Palette {
+	t.Helper()
+	var p Palette
+	for _, hex := range hexes {
+		c, err := ParseColor(hex)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Swatches = append(p.Swatches, Swatch{Color: c})
+	}
+	return p
+}
+
+func TestScorePaletteDistinctness(t *testing.T) {
+	dupes := ScorePalette(swatchPalette(t, "#3366CC", "#3467CD", "#3265CB", "#F2C14E", "#F3C24F"))
+	distinct := ScorePalette(swatchPalette(t, "#264653", "#2A9D8F", "#E9C46A", "#F4A261", "#E76F51"))
+
+	if dupes.Distinctness > 0.1 {
+		t.Errorf("near-duplicates scored %.2f on distinctness", dupes.Distinctness)
+	}
+	if distinct.Distinctness < 0.5 {
+		t.Errorf("distinct colors scored %.2f on distinctness", distinct.Distinctness)
+	}
+	if dupes.Total >= distinct.Total {
+		t.Errorf("near-duplicates scored %.2f in total, distinct colors %.2f", dupes.Total, distinct.Total)
+	}
+}
+
+func TestScorePaletteChromaBalance(t *testing.T) {
+	// Every color halfway between gray and colorful
+	var muddy Palette
+	for _, l := range []float64{0.3, 0.45, 0.6, 0.75, 0.9} {
+		c := OKLCH{L: l, C: (neutralChroma + vividChroma) / 2, H: l * 400}.ToColor()
+		muddy.Swatches = append(muddy.Swatches, Swatch{Color: c})
+	}
+	got := ScorePalette(muddy)
+	if got.ChromaBalance > 0.2 {
+		t.Errorf("muddy palette scored %.2f on chroma balance", got.ChromaBalance)
+	}
+
+	// Clear neutrals with a vivid accent
+	clean := ScorePalette(swatchPalette(t, "#111111", "#777777", "#F5F5F5", "#E63946", "#1D3557"))
+	if clean.ChromaBalance < 0.8 {
+		t.Errorf("neutrals with accents scored %.2f on chroma balance", clean.ChromaBalance)
+	}
+
+	// Grays alone have nothing colorful
+	if grays := ScorePalette(swatchPalette(t, "#111111", "#777777", "#F5F5F5")); grays.ChromaBalance > 0.01 {
+		t.Errorf("grays scored %.2f on chroma balance", grays.ChromaBalance)
+	}
+}
+
+func TestScorePaletteEmpty(t *testing.T) {
+	if got := ScorePalette(Palette{}); got != (PaletteScore{}) {
+		t.Errorf("empty palette scored %+v", got)
+	}
+}
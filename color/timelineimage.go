@@ -0,0 +1,85 @@
+package color
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"time"
+
+	"github.com/fogleman/gg"
+)
+
+// timelineHeight is the height of a palette timeline strip
+const timelineHeight = 400
+
+// TimelineImage represents configuration for a strip showing each frame's
+// palette from left to right
+type TimelineImage struct {
+	Palettes [][]Cluster
+	// Optional frame durations; columns are sized by them when given for
+	// every frame and equally otherwise
+	Delays []time.Duration
+}
+
+// GenerateTimelineImage draws one column per frame, split from top to bottom
+// between the frame's colors by population
+func GenerateTimelineImage(cfg TimelineImage) (image.Image, error) {
+	if len(cfg.Palettes) == 0 {
+		return nil, fmt.Errorf("no palettes provided")
+	}
+
+	dc := gg.NewContext(imageSize, timelineHeight)
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	weights := make([]float64, len(cfg.Delays))
+	for i, d := range cfg.Delays {
+		weights[i] = d.Seconds()
+	}
+	widths := timelineWidths(len(cfg.Palettes), weights)
+
+	x := 0.0
+	for i, palette := range cfg.Palettes {
+		total := 0.0
+		for _, c := range palette {
+			total += c.Population
+		}
+
+		y := 0.0
+		for _, c := range palette {
+			h := float64(timelineHeight) / float64(len(palette))
+			if total > 0 {
+				h = float64(timelineHeight) * c.Population / total
+			}
+			// Overlap by a pixel so antialiasing leaves no seams
+			dc.SetColor(c.Color.ToRGBA())
+			dc.DrawRectangle(x, y, widths[i]+1, h+1)
+			dc.Fill()
+			y += h
+		}
+		x += widths[i]
+	}
+
+	return dc.Image(), nil
+}
+
+// timelineWidths splits the image width between frames in proportion to
+// weights when one is given for every frame
+func timelineWidths(frames int, weights []float64) []float64 {
+	widths := make([]float64, frames)
+
+	total := 0.0
+	if len(weights) == frames {
+		for _, w := range weights {
+			total += w
+		}
+	}
+	for i := range widths {
+		if total > 0 {
+			widths[i] = float64(imageSize) * weights[i] / total
+		} else {
+			widths[i] = float64(imageSize) / float64(frames)
+		}
+	}
+	return widths
+}
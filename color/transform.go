@@ -0,0 +1,153 @@
+package color
+
+import "math"
+
+// Transform adjusts a color. Transforms are built by the functions below and
+// applied with Color.Transform or Palette.Transform, which compute them in a
+// chosen color space and map the result back into the sRGB gamut.
+type Transform func(p Polar, space ColorSpace) Polar
+
+// achromaticChroma is the chroma below which a color's hue is meaningless
+const achromaticChroma = 1e-3
+
+// Lighten raises lightness by amount, on the [0, 1] lightness scale of the space
+func Lighten(amount float64) Transform {
+	return func(p Polar, _ ColorSpace) Polar {
+		p.L += amount
+		return p
+	}
+}
+
+// Darken lowers lightness by amount, on the [0, 1] lightness scale of the space
+func Darken(amount float64) Transform {
+	return Lighten(-amount)
+}
+
+// Saturate scales chroma up by a fraction, so 0.2 gives 20% more
+func Saturate(amount float64) Transform {
+	return func(p Polar, _ ColorSpace) Polar {
+		p.C *= math.Max(1+amount, 0)
+		return p
+	}
+}
+
+// Desaturate scales chroma down by a fraction, so 1 leaves a gray
+func Desaturate(amount float64) Transform {
+	return Saturate(-amount)
+}
+
+// HueRotate turns the hue by the given number of degrees
+func HueRotate(degrees float64) Transform {
+	return func(p Polar, _ ColorSpace) Polar {
+		p.H += degrees
+		return p
+	}
+}
+
+// Invert flips lightness and turns the hue halfway round, keeping chroma. In
+// HSL this is the familiar RGB negative.
+func Invert() Transform {
+	return func(p Polar, _ ColorSpace) Polar {
+		p.L = 1 - p.L
+		p.H += 180
+		return p
+	}
+}
+
+// Grayscale removes all chroma, leaving a gray of the same lightness
+func Grayscale() Transform {
+	return func(p Polar, _ ColorSpace) Polar {
+		p.C = 0
+		return p
+	}
+}
+
+// Mix moves a color toward target by amount in [0, 1]. Hue takes the shorter
+// way round, and grays take the hue of the color they are mixed with.
+func Mix(target Color, amount float64) Transform {
+	t := clamp(amount, 0, 1)
+	return func(p Polar, space ColorSpace) Polar {
+		q := toPolar(target, space)
+		h := p.H
+		switch {
+		case p.C < achromaticChroma:
+			h = q.H
+		case q.C >= achromaticChroma:
+			h += (math.Mod(q.H-p.H+540, 360) - 180) * t
+		}
+		return Polar{
+			L: p.L + (q.L-p.L)*t,
+			C: p.C + (q.C-p.C)*t,
+			H: h,
+		}
+	}
+}
+
+// Temperature warms a color toward orange for positive amounts and cools it
+// toward blue for negative ones. At ±1 even grays pick up a clear tint.
+func Temperature(amount float64) Transform {
+	return func(p Polar, space ColorSpace) Polar {
+		warmHue, shift := 30.0, 25.0
+		if space == SpaceOKLCH {
+			warmHue, shift = 55, 0.05
+		}
+
+		// Push the color across the hue plane toward the warm hue, or away from it
+		shift *= clamp(amount, -1, 1)
+		a := p.C*math.Cos(degToRad(p.H)) + shift*math.Cos(degToRad(warmHue))
+		b := p.C*math.Sin(degToRad(p.H)) + shift*math.Sin(degToRad(warmHue))
+		p.C = math.Hypot(a, b)
+		if p.C >= achromaticChroma {
+			p.H = hueAngle(b, a)
+		}
+		return p
+	}
+}
+
+// NormalizeLightness sets lightness to l, on the [0, 1] scale of the space.
+// In OKLCH this gives colors of equal visual weight.
+func NormalizeLightness(l float64) Transform {
+	return func(p Polar, _ ColorSpace) Polar {
+		p.L = l
+		return p
+	}
+}
+
+// Transform applies the transforms in order, computed in the given space
+func (c Color) Transform(space ColorSpace, transforms ...Transform) Color {
+	p := toPolar(c, space)
+	for _, t := range transforms {
+		p = t(p, space)
+	}
+	return p.color(space)
+}
+
+// Transform returns a copy of the palette with the transforms applied to
+// every color. Swatches whose color changed lose their name, which no longer
+// fits; ColorMatcher.NameColor can name them again. Base is transformed like
+// the swatches, and Type is cleared when any color changed, since transforms
+// such as Mix or Grayscale break the harmony it describes.
+func (p Palette) Transform(space ColorSpace, transforms ...Transform) Palette {
+	changed := false
+	swatches := make([]Swatch, len(p.Swatches))
+	for i, s := range p.Swatches {
+		c := s.Color.Transform(space, transforms...)
+		if c != s.Color {
+			s.Color = c
+			s.Name = ""
+			changed = true
+		}
+		swatches[i] = s
+	}
+	p.Swatches = swatches
+
+	if p.Base != nil {
+		base := p.Base.Transform(space, transforms...)
+		changed = changed || base != *p.Base
+		p.Base = &base
+	}
+	if changed {
+		p.Type = nil
+	}
+	return p
+}
@@ -0,0 +1,59 @@
+package color_test
+
+import (
+	"testing"
+
+	"github.com/watzon/pigmentpoet/color"
+)
+
+func TestCustomTransform(t *testing.T) {
+	// Transforms can be written outside the package
+	var halveLightness color.Transform = func(p color.Polar, _ color.ColorSpace) color.Polar {
+		p.L /= 2
+		return p
+	}
+
+	red := color.Color{R: 255}
+	tests := []struct {
+		space     color.ColorSpace
+		lightness float64
+	}{
+		{color.SpaceHSL, 0.5},
+		{color.SpaceOKLCH, red.ToOKLCH().L},
+	}
+	for _, tt := range tests {
+		got := red.Transform(tt.space, halveLightness)
+		if want := red.Transform(tt.space, color.NormalizeLightness(tt.lightness/2)); got != want {
+			t.Errorf("%v: got %s, want %s", tt.space, got.Hex(), want.Hex())
+		}
+	}
+}
+
+func TestPaletteTransformBase(t *testing.T) {
+	m, err := color.NewPreloadedColorMatcher()
+	if err != nil {
+		t.Fatal(err)
+	}
+	p, err := m.NewGeneratedPalette("#3366CC", color.Triadic, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	original := *p.Base
+
+	rotated := p.Transform(color.SpaceHSL, color.HueRotate(120))
+	if want := original.Transform(color.SpaceHSL, color.HueRotate(120)); rotated.Base == nil || *rotated.Base != want {
+		t.Errorf("base %v, want %s", rotated.Base, want.Hex())
+	}
+	if rotated.Type != nil {
+		t.Errorf("type %v kept after the colors changed", *rotated.Type)
+	}
+	if *p.Base != original || p.Type == nil {
+		t.Error("original palette was modified")
+	}
+
+	// A transform that changes nothing keeps the description
+	same := p.Transform(color.SpaceHSL, color.HueRotate(0))
+	if same.Type == nil || *same.Type != color.Triadic || *same.Base != original {
+		t.Errorf("no-op transform changed type to %v and base to %v", same.Type, same.Base)
+	}
+}
@@ -0,0 +1,241 @@
+package color
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// weightGridSize is the number of cells along the longer side of the grid
+	// the spatial weights are computed on
+	weightGridSize = 64
+
+	// flatCellDeviation is the RMS channel deviation below which a cell counts
+	// as flat for background detection
+	flatCellDeviation = 8.0
+	// backgroundStep is the largest CIE76 difference between neighbouring flat
+	// cells that still joins them into one background region
+	backgroundStep = 6.0
+
+	// saliencyRadius is the surround, in cells, that local contrast is measured against
+	saliencyRadius = weightGridSize / 4
+	// centerSigma is the spread of the center prior relative to the half diagonal
+	centerSigma = 0.5
+
+	// clipLow and clipHigh bound the channels of near-black and near-white pixels
+	clipLow  = 8
+	clipHigh = 247
+)
+
+// pixelWeights holds a coarse per-cell multiplier for pixel weights
+type pixelWeights struct {
+	bounds   image.Rectangle
+	cellSize int
+	cols     int
+	weight   []float64
+}
+
+// gridCell accumulates the color statistics of one cell
+type gridCell struct {
+	count float64
+	sum   [3]float64
+	sumSq float64
+}
+
+func (c *gridCell) mean() Color {
+	return Color{
+		R: roundChannel(c.sum[0] / c.count),
+		G: roundChannel(c.sum[1] / c.count),
+		B: roundChannel(c.sum[2] / c.count),
+	}
+}
+
+// deviation returns the RMS deviation of the cell's channels from their mean
+func (c *gridCell) deviation() float64 {
+	meanSq := 0.0
+	for _, s := range c.sum {
+		m := s / c.count
+		meanSq += m * m
+	}
+	return math.Sqrt(math.Max(0, (c.sumSq/c.count-meanSq)/3))
+}
+
+// newPixelWeights builds the spatial weights requested by opts, or returns
+// nil when none are
+func newPixelWeights(img image.Image, opts ExtractOptions) *pixelWeights {
+	if opts.SuppressBackground <= 0 && opts.Saliency <= 0 && opts.CenterPrior <= 0 {
+		return nil
+	}
+
+	bounds := img.Bounds()
+	cellSize := max(1, ceilDiv(max(bounds.Dx(), bounds.Dy()), weightGridSize))
+	w := &pixelWeights{
+		bounds:   bounds,
+		cellSize: cellSize,
+		cols:     ceilDiv(bounds.Dx(), cellSize),
+	}
+	rows := ceilDiv(bounds.Dy(), cellSize)
+
+	cells := make([]gridCell, w.cols*rows)
+	scanPixels(img, bounds, max(opts.Stride, 1), opts.MinAlpha, func(x, y int, c Color, a uint8) {
+		cell := &cells[w.index(x, y)]
+		cell.count++
+		for ch, v := range channels(c) {
+			cell.sum[ch] += v
+			cell.sumSq += v * v
+		}
+	})
+
+	labs := make([]Lab, len(cells))
+	for i := range cells {
+		if cells[i].count > 0 {
+			labs[i] = cells[i].mean().ToLab()
+		}
+	}
+
+	w.weight = make([]float64, len(cells))
+	for i := range w.weight {
+		w.weight[i] = 1
+	}
+
+	if s := clamp(opts.SuppressBackground, 0, 1); s > 0 {
+		for i, bg := range backgroundCells(cells, labs, w.cols, rows) {
+			if bg {
+				w.weight[i] *= 1 - s
+			}
+		}
+	}
+
+	if s := clamp(opts.Saliency, 0, 1); s > 0 {
+		for i, v := range saliencyMap(cells, labs, w.cols, rows) {
+			w.weight[i] *= 1 - s + s*v
+		}
+	}
+
+	if s := clamp(opts.CenterPrior, 0, 1); s > 0 {
+		cx, cy := float64(w.cols)/2, float64(rows)/2
+		halfDiagonal := math.Hypot(cx, cy)
+		for i := range w.weight {
+			dx := (float64(i%w.cols) + 0.5 - cx) / halfDiagonal
+			dy := (float64(i/w.cols) + 0.5 - cy) / halfDiagonal
+			prior := math.Exp(-(dx*dx + dy*dy) / (2 * centerSigma * centerSigma))
+			w.weight[i] *= 1 - s + s*prior
+		}
+	}
+
+	return w
+}
+
+func (w *pixelWeights) index(x, y int) int {
+	return (y-w.bounds.Min.Y)/w.cellSize*w.cols + (x-w.bounds.Min.X)/w.cellSize
+}
+
+func (w *pixelWeights) at(x, y int) float64 {
+	return w.weight[w.index(x, y)]
+}
+
+// backgroundCells flood-fills from the flat cells on the image border through
+// neighbouring flat cells of similar color, marking skies, studio backdrops
+// and letterboxing
+func backgroundCells(cells []gridCell, labs []Lab, cols, rows int) []bool {
+	flat := func(i int) bool {
+		return cells[i].count > 0 && cells[i].deviation() < flatCellDeviation
+	}
+
+	background := make([]bool, len(cells))
+	var queue []int
+	for i := range cells {
+		x, y := i%cols, i/cols
+		onBorder := x == 0 || y == 0 || x == cols-1 || y == rows-1
+		if onBorder && flat(i) {
+			background[i] = true
+			queue = append(queue, i)
+		}
+	}
+
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		x, y := i%cols, i/cols
+		for _, d := range [4][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}} {
+			nx, ny := x+d[0], y+d[1]
+			if nx < 0 || ny < 0 || nx >= cols || ny >= rows {
+				continue
+			}
+			n := ny*cols + nx
+			if background[n] || !flat(n) || deltaE76(labs[i], labs[n]) > backgroundStep {
+				continue
+			}
+			background[n] = true
+			queue = append(queue, n)
+		}
+	}
+	return background
+}
+
+// saliencyMap scores each cell by how much its color differs from its
+// surroundings, normalized to [0, 1]
+func saliencyMap(cells []gridCell, labs []Lab, cols, rows int) []float64 {
+	saliency := make([]float64, len(cells))
+	highest := 0.0
+	for i := range cells {
+		if cells[i].count == 0 {
+			continue
+		}
+
+		x, y := i%cols, i/cols
+		var surround Lab
+		n := 0.0
+		for ny := max(0, y-saliencyRadius); ny <= min(rows-1, y+saliencyRadius); ny++ {
+			for nx := max(0, x-saliencyRadius); nx <= min(cols-1, x+saliencyRadius); nx++ {
+				j := ny*cols + nx
+				if cells[j].count == 0 {
+					continue
+				}
+				surround.L += labs[j].L
+				surround.A += labs[j].A
+				surround.B += labs[j].B
+				n++
+			}
+		}
+		surround = Lab{L: surround.L / n, A: surround.A / n, B: surround.B / n}
+
+		saliency[i] = deltaE76(labs[i], surround)
+		highest = math.Max(highest, saliency[i])
+	}
+
+	if highest > 0 {
+		for i := range saliency {
+			saliency[i] /= highest
+		}
+	}
+	return saliency
+}
+
+// isClipped reports whether a pixel is near black or near white, as clipped
+// highlights and shadows are
+func isClipped(c Color) bool {
+	lo := min(min(int(c.R), int(c.G)), int(c.B))
+	hi := max(max(int(c.R), int(c.G)), int(c.B))
+	return hi <= clipLow || lo >= clipHigh
+}
+
+// weightedVisitor wraps a visitor so pixel weights include the spatial
+// weights and clipping suppression requested by opts
+func weightedVisitor(img image.Image, opts ExtractOptions, visit sampleVisitor) sampleVisitor {
+	weights := newPixelWeights(img, opts)
+	clipped := clamp(opts.SuppressClipped, 0, 1)
+	if weights == nil && clipped == 0 {
+		return visit
+	}
+
+	return func(worker, x, y int, c Color, weight float64) {
+		if weights != nil {
+			weight *= weights.at(x, y)
+		}
+		if clipped > 0 && isClipped(c) {
+			weight *= 1 - clipped
+		}
+		visit(worker, x, y, c, weight)
+	}
+}
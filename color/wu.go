@@ -0,0 +1,272 @@
+package color
+
+// Wu is Xiaolin Wu's greedy orthogonal bipartition quantizer. It builds
+// cumulative color moments over a 32x32x32 grid and repeatedly cuts the box
+// with the largest variance where the cut minimizes the summed variance.
+type Wu struct{}
+
+// wuSide is the grid size per channel, with index 0 reserved for the cumulative sums
+const wuSide = 33
+
+type wuDirection int
+
+const (
+	wuRed wuDirection = iota
+	wuGreen
+	wuBlue
+)
+
+// wuBox is a box on the moment grid; lower bounds are exclusive
+type wuBox struct {
+	r0, r1 int
+	g0, g1 int
+	b0, b1 int
+}
+
+// wuMoments holds the weight, per-channel and squared moments of the histogram.
+// lr, lg and lb are linear-light channel sums used only for box colors.
+type wuMoments struct {
+	wt, mr, mg, mb, m2 []float64
+	lr, lg, lb         []float64
+}
+
+func wuIndex(r, g, b int) int {
+	return r*wuSide*wuSide + g*wuSide + b
+}
+
+// Extract implements Extractor
+func (Wu) Extract(colors []WeightedColor, k int) []Cluster {
+	if len(colors) == 0 || k < 1 {
+		return nil
+	}
+
+	m := newWuMoments(colors)
+	m.cumulate()
+
+	boxes := []wuBox{{r1: wuSide - 1, g1: wuSide - 1, b1: wuSide - 1}}
+	variances := []float64{m.variance(boxes[0])}
+
+	for len(boxes) < k {
+		// Cut the box with the most variance next
+		next := 0
+		for i := range variances {
+			if variances[i] > variances[next] {
+				next = i
+			}
+		}
+		if variances[next] <= 0 {
+			break
+		}
+
+		box2, ok := m.cut(&boxes[next])
+		if !ok {
+			variances[next] = 0
+			continue
+		}
+		boxes = append(boxes, box2)
+		variances[next] = m.variance(boxes[next])
+		variances = append(variances, m.variance(box2))
+	}
+
+	clusters := make([]Cluster, 0, len(boxes))
+	for _, box := range boxes {
+		weight := volume(box, m.wt)
+		if weight <= 0 {
+			continue
+		}
+		clusters = append(clusters, Cluster{
+			Color: linearToColor(
+				volume(box, m.lr)/weight,
+				volume(box, m.lg)/weight,
+				volume(box, m.lb)/weight,
+			),
+			Population: weight,
+		})
+	}
+	return clusters
+}
+
+func newWuMoments(colors []WeightedColor) *wuMoments {
+	size := wuSide * wuSide * wuSide
+	m := &wuMoments{
+		wt: make([]float64, size),
+		mr: make([]float64, size),
+		mg: make([]float64, size),
+		mb: make([]float64, size),
+		m2: make([]float64, size),
+		lr: make([]float64, size),
+		lg: make([]float64, size),
+		lb: make([]float64, size),
+	}
+
+	for _, c := range colors {
+		idx := wuIndex(int(c.R>>3)+1, int(c.G>>3)+1, int(c.B>>3)+1)
+		r, g, b := float64(c.R), float64(c.G), float64(c.B)
+		m.wt[idx] += c.Weight
+		m.mr[idx] += r * c.Weight
+		m.mg[idx] += g * c.Weight
+		m.mb[idx] += b * c.Weight
+		m.m2[idx] += (r*r + g*g + b*b) * c.Weight
+		m.lr[idx] += linearTable[c.R] * c.Weight
+		m.lg[idx] += linearTable[c.G] * c.Weight
+		m.lb[idx] += linearTable[c.B] * c.Weight
+	}
+
+	return m
+}
+
+// cumulate turns the histogram into 3-d prefix sums so any box can be summed in O(1)
+func (m *wuMoments) cumulate() {
+	tables := [][]float64{m.wt, m.mr, m.mg, m.mb, m.m2, m.lr, m.lg, m.lb}
+
+	for _, t := range tables {
+		for r := 1; r < wuSide; r++ {
+			var area [wuSide]float64
+			for g := 1; g < wuSide; g++ {
+				line := 0.0
+				for b := 1; b < wuSide; b++ {
+					idx := wuIndex(r, g, b)
+					line += t[idx]
+					area[b] += line
+					t[idx] = t[wuIndex(r-1, g, b)] + area[b]
+				}
+			}
+		}
+	}
+}
+
+// volume sums a moment table over a box
+func volume(box wuBox, t []float64) float64 {
+	return t[wuIndex(box.r1, box.g1, box.b1)] -
+		t[wuIndex(box.r1, box.g1, box.b0)] -
+		t[wuIndex(box.r1, box.g0, box.b1)] +
+		t[wuIndex(box.r1, box.g0, box.b0)] -
+		t[wuIndex(box.r0, box.g1, box.b1)] +
+		t[wuIndex(box.r0, box.g1, box.b0)] +
+		t[wuIndex(box.r0, box.g0, box.b1)] -
+		t[wuIndex(box.r0, box.g0, box.b0)]
+}
+
+// bottom is the part of a box's volume that does not depend on the cut position
+func bottom(box wuBox, dir wuDirection, t []float64) float64 {
+	switch dir {
+	case wuRed:
+		return -t[wuIndex(box.r0, box.g1, box.b1)] +
+			t[wuIndex(box.r0, box.g1, box.b0)] +
+			t[wuIndex(box.r0, box.g0, box.b1)] -
+			t[wuIndex(box.r0, box.g0, box.b0)]
+	case wuGreen:
+		return -t[wuIndex(box.r1, box.g0, box.b1)] +
+			t[wuIndex(box.r1, box.g0, box.b0)] +
+			t[wuIndex(box.r0, box.g0, box.b1)] -
+			t[wuIndex(box.r0, box.g0, box.b0)]
+	default:
+		return -t[wuIndex(box.r1, box.g1, box.b0)] +
+			t[wuIndex(box.r1, box.g0, box.b0)] +
+			t[wuIndex(box.r0, box.g1, box.b0)] -
+			t[wuIndex(box.r0, box.g0, box.b0)]
+	}
+}
+
+// top is the part of a box's volume below the cut position pos
+func top(box wuBox, dir wuDirection, pos int, t []float64) float64 {
+	switch dir {
+	case wuRed:
+		return t[wuIndex(pos, box.g1, box.b1)] -
+			t[wuIndex(pos, box.g1, box.b0)] -
+			t[wuIndex(pos, box.g0, box.b1)] +
+			t[wuIndex(pos, box.g0, box.b0)]
+	case wuGreen:
+		return t[wuIndex(box.r1, pos, box.b1)] -
+			t[wuIndex(box.r1, pos, box.b0)] -
+			t[wuIndex(box.r0, pos, box.b1)] +
+			t[wuIndex(box.r0, pos, box.b0)]
+	default:
+		return t[wuIndex(box.r1, box.g1, pos)] -
+			t[wuIndex(box.r1, box.g0, pos)] -
+			t[wuIndex(box.r0, box.g1, pos)] +
+			t[wuIndex(box.r0, box.g0, pos)]
+	}
+}
+
+// variance returns the weighted squared error of a box around its mean
+func (m *wuMoments) variance(box wuBox) float64 {
+	weight := volume(box, m.wt)
+	if weight <= 0 {
+		return 0
+	}
+	dr := volume(box, m.mr)
+	dg := volume(box, m.mg)
+	db := volume(box, m.mb)
+	return volume(box, m.m2) - (dr*dr+dg*dg+db*db)/weight
+}
+
+// maximize finds the cut along dir that maximizes the between-box variance,
+// returning -1 when no cut splits the weight
+func (m *wuMoments) maximize(box wuBox, dir wuDirection, first, last int, whole [4]float64) (float64, int) {
+	baseR := bottom(box, dir, m.mr)
+	baseG := bottom(box, dir, m.mg)
+	baseB := bottom(box, dir, m.mb)
+	baseW := bottom(box, dir, m.wt)
+
+	best := 0.0
+	cut := -1
+	for i := first; i < last; i++ {
+		halfR := baseR + top(box, dir, i, m.mr)
+		halfG := baseG + top(box, dir, i, m.mg)
+		halfB := baseB + top(box, dir, i, m.mb)
+		halfW := baseW + top(box, dir, i, m.wt)
+		if halfW <= 0 {
+			continue
+		}
+		score := (halfR*halfR + halfG*halfG + halfB*halfB) / halfW
+
+		halfR = whole[0] - halfR
+		halfG = whole[1] - halfG
+		halfB = whole[2] - halfB
+		halfW = whole[3] - halfW
+		if halfW <= 0 {
+			continue
+		}
+		score += (halfR*halfR + halfG*halfG + halfB*halfB) / halfW
+
+		if score > best {
+			best = score
+			cut = i
+		}
+	}
+	return best, cut
+}
+
+// cut splits box in place and returns the other half
+func (m *wuMoments) cut(box *wuBox) (wuBox, bool) {
+	whole := [4]float64{
+		volume(*box, m.mr),
+		volume(*box, m.mg),
+		volume(*box, m.mb),
+		volume(*box, m.wt),
+	}
+
+	maxR, cutR := m.maximize(*box, wuRed, box.r0+1, box.r1, whole)
+	maxG, cutG := m.maximize(*box, wuGreen, box.g0+1, box.g1, whole)
+	maxB, cutB := m.maximize(*box, wuBlue, box.b0+1, box.b1, whole)
+
+	other := *box
+	switch {
+	case maxR >= maxG && maxR >= maxB:
+		if cutR < 0 {
+			return wuBox{}, false
+		}
+		box.r1, other.r0 = cutR, cutR
+	case maxG >= maxR && maxG >= maxB:
+		box.g1, other.g0 = cutG, cutG
+	default:
+		box.b1, other.b0 = cutB, cutB
+	}
+	return other, true
+}
+
+// roundChannel rounds a channel value and clamps it to a byte
+func roundChannel(v float64) uint8 {
+	return uint8(clamp(v+0.5, 0, 255))
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/joho/godotenv"
@@ -44,6 +45,15 @@ func main() {
 		log.Fatal("Failed to create bot:", err)
 	}
 
+	// Only post random palettes that score well enough, if configured
+	if minScore := os.Getenv("MIN_PALETTE_SCORE"); minScore != "" {
+		score, err := strconv.ParseFloat(minScore, 64)
+		if err != nil || score < 0 || score > 1 {
+			log.Fatalf("MIN_PALETTE_SCORE must be a number from 0 to 1, got %q", minScore)
+		}
+		b.SetMinPaletteScore(score)
+	}
+
 	// Create a new cron scheduler with configured timezone
 	c := cron.New(cron.WithLocation(location))
 
@@ -80,6 +90,20 @@ func main() {
 		log.Fatal("Failed to schedule Bing palette cron job:", err)
 	}
 
+	// Schedule an animation palette post once per day at 5:00 PM when a
+	// source GIF is configured
+	if animationURL := os.Getenv("ANIMATION_URL"); animationURL != "" {
+		_, err = c.AddFunc("0 17 * * *", func() {
+			log.Println("Generating and posting palette from animation...")
+			if err := b.GenerateAndPostFromAnimation(ctx, animationURL); err != nil {
+				log.Printf("Error posting animation palette: %v", err)
+			}
+		})
+		if err != nil {
+			log.Fatal("Failed to schedule animation palette cron job:", err)
+		}
+	}
+
 	// Start the scheduler
 	c.Start()
 